@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+type BaselineCommand struct {
+}
+
+func (c *BaselineCommand) Help() string {
+	helpText := `
+Usage: sql-migrate baseline [options] <id>
+
+  Marks all migrations up to and including the given migration as applied,
+  without running them, for a database created before using sql-migrate.
+
+  Refuses to run if any migration was applied already, unless forced.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
+  -dryrun                Don't mark migrations, just list them.
+  -force                 Baseline even if migrations were applied already.
+  -output=table          Output format (table, json or yaml).
+  -v                     Log every migration and statement executed.
+  -quiet                 Only report errors.
+  -log-format=text       Log format (text or json).
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *BaselineCommand) Synopsis() string {
+	return "Marks the migrations of an existing database as applied, without running them"
+}
+
+func (c *BaselineCommand) Run(args []string) int {
+	var dryrun, force bool
+
+	cmdFlags := flag.NewFlagSet("baseline", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&dryrun, "dryrun", false, "Don't mark migrations, just list them.")
+	cmdFlags.BoolVar(&force, "force", false, "Baseline even if migrations were applied already.")
+	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+	LogFlags(cmdFlags)
+	OutputFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	SetupUi()
+
+	if cmdFlags.NArg() != 1 {
+		return ReportError(fmt.Errorf("A migration ID is needed"))
+	}
+
+	return Report(Baseline(cmdFlags.Arg(0), dryrun, force))
+}
+
+func Baseline(id string, dryrun, force bool) (*migrationsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+
+	migrator, err := GetMigrator(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	defer migrator.Close()
+
+	source := migrate.FileSource{
+		Dir: env.Dir,
+	}
+
+	if dryrun {
+		migrations, err := migrator.PlanBaselineContext(ctx, source, id, force)
+		if err != nil {
+			return nil, fmt.Errorf("error planning baseline: %w", err)
+		}
+
+		if !StructuredOutput() {
+			for _, m := range migrations {
+				ui.Output(fmt.Sprintf("==> Would mark %s as applied", m.ID))
+			}
+		}
+
+		return newMigrationsOutput("baseline", true, migrations, nil), nil
+	}
+
+	migrations, err := migrator.BaselineMigrationsContext(ctx, source, id, force)
+	out := newMigrationsOutput("baseline", false, migrations, nil)
+	if err != nil {
+		return out, fmt.Errorf("baseline failed: %w", err)
+	}
+
+	if StructuredOutput() {
+		return out, nil
+	}
+
+	if len(migrations) == 1 {
+		ui.Output("Marked 1 migration as applied")
+	} else {
+		ui.Output(fmt.Sprintf("Marked %d migrations as applied", len(migrations)))
+	}
+
+	return out, nil
+}
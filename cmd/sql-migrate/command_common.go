@@ -1,49 +1,235 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/shasderias/sql-migrate/pkg/config"
 	"github.com/shasderias/sql-migrate/pkg/migrate"
 )
 
-func ApplyMigrations(dir migrate.Direction, dryrun bool, limit int) error {
+// InterruptContext returns a context that is cancelled when the process
+// receives SIGINT or SIGTERM, so that the migration in flight is rolled back
+// instead of the process being killed halfway through it. The returned cancel
+// function must be called to release the signal handler.
+func InterruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		select {
+		case <-signals:
+			ui.Warn("Interrupted, aborting")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// RunMigrations applies the migrations of the environment, to every tenant in
+// multi-tenant environments, and returns the exit code of the command.
+func RunMigrations(dir migrate.Direction, dryrun bool, limit int) int {
 	env, err := GetEnvironment()
 	if err != nil {
-		return fmt.Errorf("error parsing config: %s", err)
+		return ReportError(fmt.Errorf("error parsing config: %w", err))
+	}
+
+	if env.Tenants != nil {
+		return ReportTenants(ApplyTenantMigrations(env, dir, dryrun, limit))
 	}
 
-	migrator, err := migrate.New(env.Dialect, env.DataSource, env.TableName)
+	return Report(ApplyMigrations(env, dir, dryrun, limit))
+}
+
+// ApplyMigrations applies the migrations of env.
+func ApplyMigrations(env *config.Environment, dir migrate.Direction, dryrun bool, limit int) (*migrationsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	migrator, err := GetMigrator(ctx, env)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer migrator.Close()
 
 	source := migrate.FileSource{
 		Dir: env.Dir,
 	}
 
 	if dryrun {
-		migrations, err := migrator.Plan(source, dir, limit)
+		migrations, err := migrator.PlanContext(ctx, source, dir, limit)
 		if err != nil {
-			return fmt.Errorf("error planning migration: %s", err)
+			return nil, fmt.Errorf("error planning migration: %w", err)
 		}
 
-		for _, m := range migrations {
-			PrintMigration(m, dir)
+		if !StructuredOutput() {
+			for _, m := range migrations {
+				PrintMigration(m, m.Direction)
+			}
 		}
-	} else {
-		n, err := migrator.ExecMax(source, dir, limit)
+
+		return newMigrationsOutput(dir.String(), true, migrations, nil), nil
+	}
+
+	migrations, err := migrator.ExecMaxMigrationsContext(ctx, source, dir, limit)
+	out := newMigrationsOutput(dir.String(), false, migrations, nil)
+	if err != nil {
+		return out, fmt.Errorf("migration failed: %w", err)
+	}
+
+	if !StructuredOutput() {
+		if len(migrations) == 1 {
+			ui.Output("Applied 1 migration")
+		} else {
+			ui.Output(fmt.Sprintf("Applied %d migrations", len(migrations)))
+		}
+	}
+
+	return out, nil
+}
+
+// ApplyTenantMigrations applies the migrations of env to all of its tenants.
+func ApplyTenantMigrations(env *config.Environment, dir migrate.Direction, dryrun bool, limit int) (*tenantsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	multi, err := GetMultiMigrator(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	source := migrate.FileSource{
+		Dir: env.Dir,
+	}
+
+	var mu sync.Mutex
+	plans := make(map[*migrate.Tenant][]*migrate.PlannedMigration)
+
+	results, err := multi.Run(ctx, func(ctx context.Context, tenant *migrate.Tenant, m *migrate.Migrator) (int, error) {
+		if dryrun {
+			migrations, err := m.PlanContext(ctx, source, dir, limit)
+			if err != nil {
+				return 0, fmt.Errorf("error planning migration: %w", err)
+			}
+
+			mu.Lock()
+			plans[tenant] = migrations
+			mu.Unlock()
+			return len(migrations), nil
+		}
+
+		migrations, err := m.ExecMaxMigrationsContext(ctx, source, dir, limit)
+
+		mu.Lock()
+		plans[tenant] = migrations
+		mu.Unlock()
+
 		if err != nil {
-			return fmt.Errorf("migration failed: %s", err)
+			return len(migrations), fmt.Errorf("migration failed: %w", err)
 		}
+		return len(migrations), nil
+	})
 
-		if n == 1 {
-			ui.Output("Applied 1 migration")
+	out := newTenantsOutput(dir.String(), dryrun, results, plans)
+
+	if !StructuredOutput() {
+		if dryrun {
+			for _, result := range results {
+				ui.Output(fmt.Sprintf("==> Tenant %s", result.Tenant.Name))
+				for _, m := range plans[result.Tenant] {
+					PrintMigration(m, m.Direction)
+				}
+			}
+		}
+
+		PrintTenantResults(results, dryrun)
+	}
+
+	return out, err
+}
+
+// PrintTenantResults prints a table of the outcome of every tenant.
+func PrintTenantResults(results []*migrate.TenantResult, dryrun bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	if dryrun {
+		table.SetHeader([]string{"Tenant", "Status", "Planned", "Error"})
+	} else {
+		table.SetHeader([]string{"Tenant", "Status", "Applied", "Duration", "Error"})
+	}
+	table.SetColWidth(60)
+
+	for _, result := range results {
+		row := []string{result.Tenant.Name, tenantStatus(result), strconv.Itoa(result.Applied)}
+		if !dryrun {
+			row = append(row, result.Duration.Round(time.Millisecond).String())
+		}
+		if result.Err != nil {
+			row = append(row, result.Err.Error())
 		} else {
-			ui.Output(fmt.Sprintf("Applied %d migrations", n))
+			row = append(row, "")
 		}
+		table.Append(row)
 	}
 
-	return nil
+	table.Render()
+}
+
+// ReportTenants is like Report, for multi-tenant runs. The outcome of every
+// tenant was already printed in text mode.
+func ReportTenants(out *tenantsOutput, err error) int {
+	if !StructuredOutput() || out == nil {
+		if err != nil {
+			return ReportError(err)
+		}
+		return 0
+	}
+
+	if err != nil {
+		out.Error = err.Error()
+	}
+	WriteOutput(out)
+
+	if err != nil {
+		return ExitCode(err)
+	}
+	return 0
+}
+
+// Report reports the outcome of a command, writing out as a structured
+// document if a structured output format was selected, and returns the exit
+// code of the command. out may be nil if the command failed before doing
+// anything.
+func Report(out *migrationsOutput, err error) int {
+	if !StructuredOutput() || out == nil {
+		if err != nil {
+			return ReportError(err)
+		}
+		return 0
+	}
+
+	if err != nil {
+		out.Error = err.Error()
+	}
+	WriteOutput(out)
+
+	if err != nil {
+		return ExitCode(err)
+	}
+	return 0
 }
 
 func PrintMigration(m *migrate.PlannedMigration, dir migrate.Direction) {
@@ -53,11 +239,18 @@ func PrintMigration(m *migrate.PlannedMigration, dir migrate.Direction) {
 		for _, q := range m.Up {
 			ui.Output(q)
 		}
+		if m.UpFunc != nil {
+			ui.Output("-- Go function")
+		}
 	case migrate.Down:
 		ui.Output(fmt.Sprintf("==> Will apply migration %s (down)", m.ID))
 		for _, q := range m.Down {
 			ui.Output(q)
 		}
+		if m.DownFunc != nil {
+			ui.Output("-- Go function")
+		}
+	default:
+		panic("unreachable code reached")
 	}
-	panic("unreachable code reached")
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type ForceCommand struct {
+}
+
+func (c *ForceCommand) Help() string {
+	helpText := `
+Usage: sql-migrate force [options] -clean|-applied <id>
+
+  Resolve the state of a dirty migration.
+
+  A migration that runs without a transaction is marked as dirty until it
+  completes. If it fails halfway, no further migrations are applied until
+  the database has been fixed by hand and the migration has been resolved
+  with this command.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
+  -clean                 Mark the migration as not applied.
+  -applied               Mark the migration as applied.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ForceCommand) Synopsis() string {
+	return "Resolve the state of a dirty migration"
+}
+
+func (c *ForceCommand) Run(args []string) int {
+	var clean, applied bool
+
+	cmdFlags := flag.NewFlagSet("force", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&clean, "clean", false, "Mark the migration as not applied.")
+	cmdFlags.BoolVar(&applied, "applied", false, "Mark the migration as applied.")
+	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if clean == applied {
+		ui.Error("Exactly one of -clean and -applied is needed")
+		return 1
+	}
+
+	if cmdFlags.NArg() != 1 {
+		ui.Error("A migration ID is needed")
+		return 1
+	}
+	id := cmdFlags.Arg(0)
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
+	}
+
+	migrator, err := GetMigrator(ctx, env)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer migrator.Close()
+
+	if clean {
+		err = migrator.ForceClean(ctx, id)
+	} else {
+		err = migrator.ForceApplied(ctx, id)
+	}
+	if err != nil {
+		return ReportError(err)
+	}
+
+	if clean {
+		ui.Output(fmt.Sprintf("Marked migration %s as not applied", id))
+	} else {
+		ui.Output(fmt.Sprintf("Marked migration %s as applied", id))
+	}
+
+	return 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+type GotoCommand struct {
+}
+
+func (c *GotoCommand) Help() string {
+	helpText := `
+Usage: sql-migrate goto [options] <id>
+
+  Migrates the database up or down to the given migration.
+
+  If the migration has not been applied yet, it is applied along with all
+  pending migrations before it. Otherwise all applied migrations after it
+  are undone. Unless migrations are undone, the repeatable migrations that
+  changed are applied last.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
+  -dryrun                Don't apply migrations, just print them.
+  -output=table          Output format (table, json or yaml).
+  -v                     Log every migration and statement executed.
+  -quiet                 Only report errors.
+  -log-format=text       Log format (text or json).
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *GotoCommand) Synopsis() string {
+	return "Migrates the database up or down to the given migration"
+}
+
+func (c *GotoCommand) Run(args []string) int {
+	var dryrun bool
+
+	cmdFlags := flag.NewFlagSet("goto", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&dryrun, "dryrun", false, "Don't apply migrations, just print them.")
+	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+	LogFlags(cmdFlags)
+	OutputFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	SetupUi()
+
+	if cmdFlags.NArg() != 1 {
+		return ReportError(fmt.Errorf("A migration ID is needed"))
+	}
+
+	return Report(MigrateTo(cmdFlags.Arg(0), dryrun))
+}
+
+func MigrateTo(id string, dryrun bool) (*migrationsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+
+	migrator, err := GetMigrator(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	defer migrator.Close()
+
+	source := migrate.FileSource{
+		Dir: env.Dir,
+	}
+
+	if dryrun {
+		migrations, err := migrator.PlanToContext(ctx, source, id)
+		if err != nil {
+			return nil, fmt.Errorf("error planning migration: %w", err)
+		}
+
+		if !StructuredOutput() {
+			for _, m := range migrations {
+				PrintMigration(m, m.Direction)
+			}
+		}
+
+		return newMigrationsOutput("goto", true, migrations, nil), nil
+	}
+
+	migrations, err := migrator.ExecToMigrationsContext(ctx, source, id)
+	out := newMigrationsOutput("goto", false, migrations, nil)
+	if err != nil {
+		return out, fmt.Errorf("migration failed: %w", err)
+	}
+
+	if !StructuredOutput() {
+		if len(migrations) == 1 {
+			ui.Output("Applied 1 migration")
+		} else {
+			ui.Output(fmt.Sprintf("Applied %d migrations", len(migrations)))
+		}
+	}
+
+	return out, nil
+}
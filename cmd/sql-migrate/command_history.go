@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+type HistoryCommand struct {
+}
+
+func (c *HistoryCommand) Help() string {
+	helpText := `
+Usage: sql-migrate history [options] ...
+
+  Show the history of operations performed on migrations, most recent first.
+
+  The history is only recorded for environments with history enabled in the
+  configuration file.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -migration=""          Only show operations on this migration.
+  -operation=""          Only show operations of this kind (up, down, skip, redo, force or baseline).
+  -since=""              Only show operations started at or after this time (RFC 3339 or YYYY-MM-DD).
+  -until=""              Only show operations started before this time (RFC 3339 or YYYY-MM-DD).
+  -failed                Only show failed operations.
+  -limit=50              Limit the number of operations shown (0 = unlimited).
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryCommand) Synopsis() string {
+	return "Show the history of operations performed on migrations"
+}
+
+func (c *HistoryCommand) Run(args []string) int {
+	var (
+		filter       migrate.HistoryFilter
+		operation    string
+		since, until string
+	)
+
+	cmdFlags := flag.NewFlagSet("history", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.StringVar(&filter.MigrationID, "migration", "", "Only show operations on this migration.")
+	cmdFlags.StringVar(&operation, "operation", "", "Only show operations of this kind.")
+	cmdFlags.StringVar(&since, "since", "", "Only show operations started at or after this time.")
+	cmdFlags.StringVar(&until, "until", "", "Only show operations started before this time.")
+	cmdFlags.BoolVar(&filter.FailedOnly, "failed", false, "Only show failed operations.")
+	cmdFlags.IntVar(&filter.Limit, "limit", 50, "Limit the number of operations shown.")
+	ConfigFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	var err error
+	if operation != "" {
+		if filter.Operation, err = migrate.ParseOperation(operation); err != nil {
+			return ReportError(fmt.Errorf("Invalid -operation: %w", err))
+		}
+	}
+	if filter.Since, err = parseTime(since); err != nil {
+		return ReportError(fmt.Errorf("Invalid -since: %w", err))
+	}
+	if filter.Until, err = parseTime(until); err != nil {
+		return ReportError(fmt.Errorf("Invalid -until: %w", err))
+	}
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
+	}
+
+	migrator, err := GetMigrator(ctx, env)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.EnableHistory(ctx); err != nil {
+		return ReportError(err)
+	}
+
+	entries, err := migrator.HistoryEntries(ctx, &filter)
+	if err != nil {
+		return ReportError(err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Started", "Migration", "Operation", "Direction", "Duration", "Outcome", "Applied By", "Host", "Version"})
+	table.SetColWidth(60)
+
+	for _, e := range entries {
+		outcome := "ok"
+		if !e.Success {
+			outcome = "failed: " + e.Error
+		}
+
+		table.Append([]string{
+			e.StartedAt.String(),
+			e.MigrationID,
+			string(e.Operation),
+			e.Direction.String(),
+			e.Duration.String(),
+			outcome,
+			e.AppliedBy,
+			e.Hostname,
+			e.ToolVersion,
+		})
+	}
+
+	table.Render()
+
+	return 0
+}
+
+// parseTime parses a time given on the command line, either as RFC 3339 or as
+// a date in local time. An empty string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type LockCommand struct {
+}
+
+func (c *LockCommand) Help() string {
+	helpText := `
+Usage: sql-migrate lock <subcommand> [options] ...
+
+  Inspect and release the migration lock.
+
+  The lock is held while migrations are applied, so that concurrent runs
+  against the same database don't interfere with each other.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LockCommand) Synopsis() string {
+	return "Inspect and release the migration lock"
+}
+
+func (c *LockCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+type LockStatusCommand struct {
+}
+
+func (c *LockStatusCommand) Help() string {
+	helpText := `
+Usage: sql-migrate lock status [options] ...
+
+  Show whether the migration lock is held, and by whom.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LockStatusCommand) Synopsis() string {
+	return "Show the state of the migration lock"
+}
+
+func (c *LockStatusCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("lock status", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	ConfigFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
+	}
+
+	migrator, err := GetLockMigrator(ctx, env)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer migrator.Close()
+
+	status, err := migrator.LockStatus(ctx)
+	if err != nil {
+		return ReportError(err)
+	}
+
+	if !status.Locked {
+		ui.Output("Not locked")
+	} else if status.Holder == "" {
+		ui.Output("Locked")
+	} else {
+		ui.Output(fmt.Sprintf("Locked by %s", status.Holder))
+	}
+
+	return 0
+}
+
+type LockForceUnlockCommand struct {
+}
+
+func (c *LockForceUnlockCommand) Help() string {
+	helpText := `
+Usage: sql-migrate lock force-unlock [options] ...
+
+  Release the migration lock, regardless of who holds it.
+
+  Only use this to recover from a process that died or hung while holding
+  the lock. On PostgreSQL, the sessions holding the lock are terminated.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LockForceUnlockCommand) Synopsis() string {
+	return "Release the migration lock held by another process"
+}
+
+func (c *LockForceUnlockCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("lock force-unlock", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	ConfigFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
+	}
+
+	migrator, err := GetLockMigrator(ctx, env)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.ForceUnlock(ctx); err != nil {
+		return ReportError(fmt.Errorf("Could not release lock: %w", err))
+	}
+
+	ui.Output("Lock released")
+
+	return 0
+}
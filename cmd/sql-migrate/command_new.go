@@ -51,8 +51,7 @@ func (c *NewCommand) Run(args []string) int {
 
 	if len(args) < 1 {
 		err := errors.New("A name for the migration is needed")
-		ui.Error(err.Error())
-		return 1
+		return ReportError(err)
 	}
 
 	if err := cmdFlags.Parse(args); err != nil {
@@ -60,8 +59,7 @@ func (c *NewCommand) Run(args []string) int {
 	}
 
 	if err := CreateMigration(cmdFlags.Arg(0)); err != nil {
-		ui.Error(err.Error())
-		return 1
+		return ReportError(err)
 	}
 	return 0
 }
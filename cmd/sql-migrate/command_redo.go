@@ -21,7 +21,12 @@ Options:
 
   -config=dbconfig.yml   Configuration file to use.
   -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
   -dryrun                Don't apply migrations, just print them.
+  -output=table          Output format (table, json or yaml).
+  -v                     Log every migration and statement executed.
+  -quiet                 Only report errors.
+  -log-format=text       Log format (text or json).
 
 `
 	return strings.TrimSpace(helpText)
@@ -38,51 +43,84 @@ func (c *RedoCommand) Run(args []string) int {
 	cmdFlags.Usage = func() { ui.Output(c.Help()) }
 	cmdFlags.BoolVar(&dryrun, "dryrun", false, "Don't apply migrations, just print them.")
 	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+	LogFlags(cmdFlags)
+	OutputFlags(cmdFlags)
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	SetupUi()
+
+	return Report(RedoMigration(dryrun))
+}
+
+func RedoMigration(dryrun bool) (*migrationsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
 
 	env, err := GetEnvironment()
 	if err != nil {
-		ui.Error(fmt.Sprintf("Could not parse config: %s", err))
-		return 1
+		return nil, fmt.Errorf("error parsing config: %w", err)
 	}
 
-	migrator, err := migrate.New(env.Dialect, env.DataSource, env.TableName)
+	migrator, err := GetMigrator(ctx, env)
 	if err != nil {
-		ui.Error(err.Error())
-		return 1
+		return nil, err
 	}
+	defer migrator.Close()
 
 	source := migrate.FileSource{
 		Dir: env.Dir,
 	}
 
-	migrations, err := migrator.Plan(source, migrate.Down, 1)
-	if len(migrations) == 0 {
-		ui.Output("Nothing to do!")
-		return 0
-	}
-
 	if dryrun {
-		PrintMigration(migrations[0], migrate.Down)
-		PrintMigration(migrations[0], migrate.Up)
-	} else {
-		_, err := migrator.ExecMax(source, migrate.Down, 1)
+		plan, err := migrator.PlanRedoContext(ctx, source)
 		if err != nil {
-			ui.Error(fmt.Sprintf("Migration (down) failed: %s", err))
-			return 1
+			return nil, fmt.Errorf("error planning migration: %w", err)
+		}
+		if len(plan) == 0 {
+			if !StructuredOutput() {
+				ui.Output("Nothing to do!")
+			}
+			return newMigrationsOutput("redo", true, nil, nil), nil
 		}
 
-		_, err = migrator.ExecMax(source, migrate.Up, 1)
-		if err != nil {
-			ui.Error(fmt.Sprintf("Migration (up) failed: %s", err))
-			return 1
+		if !StructuredOutput() {
+			PrintMigration(plan[0], migrate.Down)
+			PrintMigration(plan[1], migrate.Up)
+		}
+		return newMigrationsOutput("redo", true, plan, nil), nil
+	}
+
+	migration, err := migrator.RedoContext(ctx, source)
+	if err != nil {
+		return newMigrationsOutput("redo", false, nil, nil), fmt.Errorf("redo failed: %w", err)
+	}
+	if migration == nil {
+		if !StructuredOutput() {
+			ui.Output("Nothing to do!")
 		}
+		return newMigrationsOutput("redo", false, nil, nil), nil
+	}
 
-		ui.Output(fmt.Sprintf("Reapplied migration %s.", migrations[0].ID))
+	if !StructuredOutput() {
+		ui.Output(fmt.Sprintf("Reapplied migration %s.", migration.ID))
 	}
 
-	return 0
+	plan := []*migrate.PlannedMigration{
+		{
+			Migration:          migration,
+			Direction:          migrate.Down,
+			Queries:            migration.Down,
+			DisableTransaction: migration.DisableTransactionDown,
+		},
+		{
+			Migration:          migration,
+			Direction:          migrate.Up,
+			Queries:            migration.Up,
+			DisableTransaction: migration.DisableTransactionUp,
+		},
+	}
+	return newMigrationsOutput("redo", false, plan, nil), nil
 }
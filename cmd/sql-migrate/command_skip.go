@@ -21,7 +21,12 @@ Options:
 
   -config=dbconfig.yml   Configuration file to use.
   -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
   -limit=0               Limit the number of migrations (0 = unlimited).
+  -output=table          Output format (table, json or yaml).
+  -v                     Log every migration and statement executed.
+  -quiet                 Only report errors.
+  -log-format=text       Log format (text or json).
 
 `
 	return strings.TrimSpace(helpText)
@@ -39,41 +44,48 @@ func (c *SkipCommand) Run(args []string) int {
 	cmdFlags.Usage = func() { ui.Output(c.Help()) }
 	cmdFlags.IntVar(&limit, "limit", 0, "Max number of migrations to skip.")
 	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+	LogFlags(cmdFlags)
+	OutputFlags(cmdFlags)
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	SetupUi()
 
-	err := SkipMigrations(migrate.Up, dryrun, limit)
-	if err != nil {
-		ui.Error(err.Error())
-		return 1
-	}
-
-	return 0
+	return Report(SkipMigrations(migrate.Up, dryrun, limit))
 }
 
-func SkipMigrations(dir migrate.Direction, dryrun bool, limit int) error {
+func SkipMigrations(dir migrate.Direction, dryrun bool, limit int) (*migrationsOutput, error) {
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
 	env, err := GetEnvironment()
 	if err != nil {
-		return fmt.Errorf("error parsing config: %s", err)
+		return nil, fmt.Errorf("error parsing config: %w", err)
 	}
 
-	migrator, err := migrate.New(env.Dialect, env.DataSource, env.TableName)
+	migrator, err := GetMigrator(ctx, env)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer migrator.Close()
 
 	source := migrate.FileSource{
 		Dir: env.Dir,
 	}
 
-	n, err := migrator.SkipMax(source, dir, limit)
+	migrations, err := migrator.SkipMaxMigrationsContext(ctx, source, dir, limit)
+	out := newMigrationsOutput("skip", false, migrations, nil)
 	if err != nil {
-		return fmt.Errorf("migration failed: %s", err)
+		return out, fmt.Errorf("migration failed: %w", err)
+	}
+
+	if StructuredOutput() {
+		return out, nil
 	}
 
-	switch n {
+	switch n := len(migrations); n {
 	case 0:
 		ui.Output("All migrations have already been applied")
 	case 1:
@@ -82,5 +94,5 @@ func SkipMigrations(dir migrate.Direction, dryrun bool, limit int) error {
 		ui.Output(fmt.Sprintf("Skipped %d migrations", n))
 	}
 
-	return nil
+	return out, nil
 }
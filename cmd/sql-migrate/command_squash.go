@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+	"github.com/shasderias/sql-migrate/pkg/sqlparse"
+)
+
+type SquashCommand struct {
+}
+
+func (c *SquashCommand) Help() string {
+	helpText := `
+Usage: sql-migrate squash [options] <from> <to>
+
+  Squashes the migrations from <from> to <to>, inclusive, into a single
+  migration named after <to>, and deletes them.
+
+  Databases that applied the squashed migrations treat the new migration
+  as applied. Only squash migrations that were applied to every database,
+  or to none.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -dryrun                Don't write the migration, just print it.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SquashCommand) Synopsis() string {
+	return "Squashes a range of migrations into a single migration"
+}
+
+func (c *SquashCommand) Run(args []string) int {
+	var dryrun bool
+
+	cmdFlags := flag.NewFlagSet("squash", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&dryrun, "dryrun", false, "Don't write the migration, just print it.")
+	ConfigFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if cmdFlags.NArg() != 2 {
+		ui.Error("A range of migration IDs is needed")
+		return 1
+	}
+
+	if err := SquashMigrations(cmdFlags.Arg(0), cmdFlags.Arg(1), dryrun); err != nil {
+		return ReportError(err)
+	}
+
+	return 0
+}
+
+func SquashMigrations(from, to string, dryrun bool) error {
+	env, err := GetEnvironment()
+	if err != nil {
+		return fmt.Errorf("error parsing config: %w", err)
+	}
+
+	migrations, err := migrate.FileSource{Dir: env.Dir}.Find()
+	if err != nil {
+		return err
+	}
+
+	first, last := -1, -1
+	for i, m := range migrations {
+		if m.ID == from {
+			first = i
+		}
+		if m.ID == to {
+			last = i
+		}
+	}
+	switch {
+	case first < 0:
+		return fmt.Errorf("%w: %s", migrate.ErrUnknownMigration, from)
+	case last < 0:
+		return fmt.Errorf("%w: %s", migrate.ErrUnknownMigration, to)
+	case first > last:
+		return fmt.Errorf("migration %s comes after %s", from, to)
+	}
+	// Repeatable migrations within the range are left alone.
+	var squashed []*migrate.Migration
+	for _, m := range migrations[first : last+1] {
+		if !m.Repeatable {
+			squashed = append(squashed, m)
+		}
+	}
+
+	id := strings.TrimSuffix(to, ".sql") + "-squashed.sql"
+	migration, err := migrate.Squash(id, squashed)
+	if err != nil {
+		return err
+	}
+
+	content := sqlparse.Format(&sqlparse.ParsedMigration{
+		UpStatements:           migration.Up,
+		DownStatements:         migration.Down,
+		DisableTransactionUp:   migration.DisableTransactionUp,
+		DisableTransactionDown: migration.DisableTransactionDown,
+		Squashes:               migration.Squashes,
+	})
+
+	if dryrun {
+		ui.Output(string(content))
+		return nil
+	}
+
+	pathName := filepath.Join(env.Dir, id)
+	if _, err := os.Stat(pathName); err == nil {
+		return fmt.Errorf("migration %s exists already", pathName)
+	}
+	if err := os.WriteFile(pathName, content, 0644); err != nil {
+		return err
+	}
+
+	for _, m := range squashed {
+		if err := os.Remove(filepath.Join(env.Dir, m.ID)); err != nil {
+			return err
+		}
+	}
+
+	ui.Output(fmt.Sprintf("Squashed %d migrations into %s", len(squashed), pathName))
+	return nil
+}
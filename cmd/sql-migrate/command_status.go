@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/shasderias/sql-migrate/pkg/migrate"
+	"github.com/shasderias/sql-migrate/pkg/prommigrate"
 )
 
 type StatusCommand struct {
@@ -25,6 +26,10 @@ Options:
 
   -config=dbconfig.yml   Configuration file to use.
   -env="development"     Environment.
+  -verbose               Show who applied each migration, when and how long it took.
+  -output=table          Output format (table, json or yaml).
+  -metrics-file=""       Also write Prometheus metrics to this file, in the
+                         format of the node exporter textfile collector.
 
 `
 	return strings.TrimSpace(helpText)
@@ -35,25 +40,34 @@ func (c *StatusCommand) Synopsis() string {
 }
 
 func (c *StatusCommand) Run(args []string) int {
+	var verbose bool
+	var metricsFile string
+
 	cmdFlags := flag.NewFlagSet("status", flag.ContinueOnError)
 	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&verbose, "verbose", false, "Show who applied each migration, when and how long it took.")
+	cmdFlags.StringVar(&metricsFile, "metrics-file", "", "Also write Prometheus metrics to this file.")
 	ConfigFlags(cmdFlags)
+	OutputFlags(cmdFlags)
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	SetupUi()
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
 
 	env, err := GetEnvironment()
 	if err != nil {
-		ui.Error(fmt.Sprintf("Could not parse config: %s", err))
-		return 1
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
 	}
 
-	migrator, err := migrate.New(env.Dialect, env.DataSource, env.TableName)
+	migrator, err := GetMigrator(ctx, env)
 	if err != nil {
-		ui.Error(err.Error())
-		return 1
+		return ReportError(err)
 	}
+	defer migrator.Close()
 
 	source := migrate.FileSource{
 		Dir: env.Dir,
@@ -61,18 +75,32 @@ func (c *StatusCommand) Run(args []string) int {
 
 	migrations, err := source.Find()
 	if err != nil {
-		ui.Error(err.Error())
-		return 1
+		return ReportError(err)
 	}
 
-	records, err := migrator.Records()
+	records, err := migrator.Records(ctx)
 	if err != nil {
-		ui.Error(err.Error())
-		return 1
+		return ReportError(err)
+	}
+	records = migrate.ResolveSquashedRecords(migrations, records)
+
+	if metricsFile != "" {
+		if err := WriteMetrics(metricsFile, migrator, source); err != nil {
+			return ReportError(fmt.Errorf("Could not write metrics: %w", err))
+		}
+	}
+
+	if StructuredOutput() {
+		WriteOutput(newStatusOutput(migrations, records))
+		return 0
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Migration", "Applied"})
+	if verbose {
+		table.SetHeader([]string{"Migration", "Applied", "Direction", "Duration", "Applied By", "Host", "Version"})
+	} else {
+		table.SetHeader([]string{"Migration", "Applied"})
+	}
 	table.SetColWidth(60)
 
 	rows := make(map[string]*statusRow)
@@ -91,19 +119,42 @@ func (c *StatusCommand) Run(args []string) int {
 		}
 
 		rows[r.ID].Migrated = true
-		rows[r.ID].AppliedAt = r.AppliedAt
+		rows[r.ID].Record = r
 	}
 
 	for _, m := range migrations {
-		if rows[m.ID] != nil && rows[m.ID].Migrated {
+		row := rows[m.ID]
+
+		if !row.Migrated {
+			table.Append([]string{
+				m.ID,
+				"no",
+			})
+			continue
+		}
+
+		applied := row.Record.AppliedAt.String()
+		if row.Record.Dirty {
+			applied += " (dirty)"
+		}
+		if row.Record.Baseline {
+			applied += " (baseline)"
+		}
+
+		if verbose {
 			table.Append([]string{
 				m.ID,
-				rows[m.ID].AppliedAt.String(),
+				applied,
+				row.Record.Direction.String(),
+				row.Record.Duration.String(),
+				row.Record.AppliedBy,
+				row.Record.Hostname,
+				row.Record.ToolVersion,
 			})
 		} else {
 			table.Append([]string{
 				m.ID,
-				"no",
+				applied,
 			})
 		}
 	}
@@ -114,7 +165,18 @@ func (c *StatusCommand) Run(args []string) int {
 }
 
 type statusRow struct {
-	ID        string
-	Migrated  bool
-	AppliedAt time.Time
+	ID       string
+	Migrated bool
+	Record   *migrate.Record
+}
+
+// WriteMetrics writes the metrics of the migrations of src to filename, in
+// the format of the node exporter textfile collector.
+func WriteMetrics(filename string, migrator *migrate.Migrator, src migrate.Source) error {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(prommigrate.NewCollector(migrator, src)); err != nil {
+		return err
+	}
+
+	return prometheus.WriteToTextfile(filename, reg)
 }
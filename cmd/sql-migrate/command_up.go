@@ -20,8 +20,15 @@ Options:
 
   -config=dbconfig.yml   Configuration file to use.
   -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
   -limit=0               Limit the number of migrations (0 = unlimited).
   -dryrun                Don't apply migrations, just print them.
+  -output=table          Output format (table, json or yaml).
+  -v                     Log every migration and statement executed.
+  -quiet                 Only report errors.
+  -log-format=text       Log format (text or json).
+  -parallel=1            Number of tenants migrated concurrently.
+  -continue-on-error     Keep migrating the remaining tenants once one failed.
 
 `
 	return strings.TrimSpace(helpText)
@@ -40,16 +47,15 @@ func (c *UpCommand) Run(args []string) int {
 	cmdFlags.IntVar(&limit, "limit", 0, "Max number of migrations to apply.")
 	cmdFlags.BoolVar(&dryrun, "dryrun", false, "Don't apply migrations, just print them.")
 	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+	LogFlags(cmdFlags)
+	OutputFlags(cmdFlags)
+	TenantFlags(cmdFlags)
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	SetupUi()
 
-	err := ApplyMigrations(migrate.Up, dryrun, limit)
-	if err != nil {
-		ui.Error(err.Error())
-		return 1
-	}
-
-	return 0
+	return RunMigrations(migrate.Up, dryrun, limit)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+type VerifyCommand struct {
+}
+
+func (c *VerifyCommand) Help() string {
+	helpText := `
+Usage: sql-migrate verify [options] ...
+
+  Verify that applied migrations were not modified after they were applied.
+
+Options:
+
+  -config=dbconfig.yml   Configuration file to use.
+  -env="development"     Environment.
+  -lock-timeout=0        Maximum time to wait for the migration lock (0 = no limit).
+  -repair                Accept modified migrations by updating their recorded checksums.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VerifyCommand) Synopsis() string {
+	return "Verify that applied migrations were not modified"
+}
+
+func (c *VerifyCommand) Run(args []string) int {
+	var repair bool
+
+	cmdFlags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	cmdFlags.Usage = func() { ui.Output(c.Help()) }
+	cmdFlags.BoolVar(&repair, "repair", false, "Accept modified migrations by updating their recorded checksums.")
+	ConfigFlags(cmdFlags)
+	LockFlags(cmdFlags)
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	ctx, cancel := InterruptContext()
+	defer cancel()
+
+	env, err := GetEnvironment()
+	if err != nil {
+		return ReportError(fmt.Errorf("Could not parse config: %w", err))
+	}
+
+	migrator, err := GetMigrator(ctx, env)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer migrator.Close()
+
+	source := migrate.FileSource{
+		Dir: env.Dir,
+	}
+
+	if repair {
+		n, err := migrator.Repair(ctx, source)
+		if err != nil {
+			return ReportError(fmt.Errorf("Repair failed: %w", err))
+		}
+
+		if n == 1 {
+			ui.Output("Repaired 1 checksum")
+		} else {
+			ui.Output(fmt.Sprintf("Repaired %d checksums", n))
+		}
+
+		return 0
+	}
+
+	mismatches, err := migrator.Verify(ctx, source)
+	if err != nil {
+		return ReportError(err)
+	}
+
+	if len(mismatches) == 0 {
+		ui.Output("All applied migrations match their checksums")
+		return 0
+	}
+
+	for _, mismatch := range mismatches {
+		ui.Error(fmt.Sprintf("Migration %s was modified after it was applied at %s",
+			mismatch.Migration.ID, mismatch.Record.AppliedAt))
+	}
+
+	return exitChecksumMismatch
+}
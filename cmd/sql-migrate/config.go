@@ -1,20 +1,175 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"sort"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/shasderias/sql-migrate/pkg/config"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
 )
 
 var ConfigFile string
 var ConfigEnvironment string
+var LockTimeout time.Duration
+var Parallelism int
+var ContinueOnError bool
 
 func ConfigFlags(f *flag.FlagSet) {
 	f.StringVar(&ConfigFile, "config", "dbconfig.yml", "Configuration file to use.")
 	f.StringVar(&ConfigEnvironment, "env", "development", "Environment to use.")
 }
 
+func LockFlags(f *flag.FlagSet) {
+	f.DurationVar(&LockTimeout, "lock-timeout", 0, "Maximum time to wait for the migration lock (0 = no limit).")
+}
+
+func TenantFlags(f *flag.FlagSet) {
+	f.IntVar(&Parallelism, "parallel", 1, "Number of tenants migrated concurrently.")
+	f.BoolVar(&ContinueOnError, "continue-on-error", false, "Keep migrating the remaining tenants once one failed.")
+}
+
+func OutputFlags(f *flag.FlagSet) {
+	f.Var(&OutputFormat, "output", "Output format: table, json or yaml.")
+}
+
 func GetEnvironment() (*config.Environment, error) {
 	return config.Get(ConfigFile, ConfigEnvironment)
 }
+
+func GetMigrator(ctx context.Context, env *config.Environment) (*migrate.Migrator, error) {
+	if env.Tenants != nil {
+		return nil, fmt.Errorf("multi-tenant environments are only supported by up and down")
+	}
+
+	migrator, err := migrate.NewFromConfig(ctx, env.Dialect, getDBConfig(env))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setupMigrator(ctx, migrator, env); err != nil {
+		migrator.Close()
+		return nil, err
+	}
+
+	return migrator, nil
+}
+
+// GetLockMigrator returns a Migrator for inspecting or releasing the
+// migration lock of env. Unlike GetMigrator, it does not take the lock to
+// create the record table, so it does not wait for the holder of the lock.
+func GetLockMigrator(ctx context.Context, env *config.Environment) (*migrate.Migrator, error) {
+	if env.Tenants != nil {
+		return nil, fmt.Errorf("multi-tenant environments are only supported by up and down")
+	}
+
+	migrator, err := migrate.Connect(ctx, env.Dialect, getDBConfig(env))
+	if err != nil {
+		return nil, err
+	}
+	migrator.Logger = GetLogger()
+
+	return migrator, nil
+}
+
+func getDBConfig(env *config.Environment) *migrate.DBConfig {
+	return &migrate.DBConfig{
+		Datasource: env.DataSource,
+		TableName:  env.TableName,
+		Schema:     env.Schema,
+		SearchPath: env.SearchPath,
+
+		LockTimeout: LockTimeout,
+	}
+}
+
+// setupMigrator configures migrator with the flags and env.
+func setupMigrator(ctx context.Context, migrator *migrate.Migrator, env *config.Environment) error {
+	migrator.ToolVersion = version
+	migrator.Logger = GetLogger()
+
+	if env.History {
+		if err := migrator.EnableHistory(ctx); err != nil {
+			return fmt.Errorf("error enabling history: %w", err)
+		}
+	}
+
+	if env.Hooks != nil {
+		before, err := parseHooks(env.Hooks.Before)
+		if err != nil {
+			return err
+		}
+		after, err := parseHooks(env.Hooks.After)
+		if err != nil {
+			return err
+		}
+		migrator.Hooks = &migrate.SQLHooks{Before: before, After: after}
+	}
+
+	return nil
+}
+
+// parseHooks parses the hook files with the given names.
+func parseHooks(names []string) ([]*migrate.Migration, error) {
+	hooks := make([]*migrate.Migration, 0, len(names))
+	for _, name := range names {
+		hook, err := migrate.ParseFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading hook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, nil
+}
+
+// GetMultiMigrator returns a MultiMigrator for the tenants of env.
+func GetMultiMigrator(ctx context.Context, env *config.Environment) (*migrate.MultiMigrator, error) {
+	var (
+		tenants []*migrate.Tenant
+		err     error
+	)
+
+	switch {
+	case env.Tenants.Query != "":
+		tenants, err = migrate.DiscoverSchemaTenants(ctx, env.Dialect, getDBConfig(env), env.Tenants.Query)
+		if err != nil {
+			return nil, err
+		}
+	case len(env.Tenants.Schemas) > 0:
+		tenants = migrate.SchemaTenants(env.Dialect, getDBConfig(env), env.Tenants.Schemas)
+	default:
+		names := make([]string, 0, len(env.Tenants.DataSources))
+		for name := range env.Tenants.DataSources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			dbConfig := getDBConfig(env)
+			dbConfig.Datasource = env.Tenants.DataSources[name]
+
+			tenants = append(tenants, &migrate.Tenant{
+				Name:    name,
+				Dialect: env.Dialect,
+				Config:  dbConfig,
+			})
+		}
+	}
+
+	return &migrate.MultiMigrator{
+		Tenants:         tenants,
+		Parallelism:     Parallelism,
+		ContinueOnError: ContinueOnError,
+		Setup: func(ctx context.Context, tenant *migrate.Tenant, m *migrate.Migrator) error {
+			if err := setupMigrator(ctx, m, env); err != nil {
+				return err
+			}
+			m.Logger = GetLogger().With("tenant", tenant.Name)
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+var Verbose bool
+var Quiet bool
+var LogFormat = logFormat("text")
+
+func LogFlags(f *flag.FlagSet) {
+	f.BoolVar(&Verbose, "v", false, "Log every migration and statement executed.")
+	f.BoolVar(&Quiet, "quiet", false, "Only report errors.")
+	f.Var(&LogFormat, "log-format", "Log format: text or json.")
+}
+
+// quietUi only reports warnings and errors.
+type quietUi struct {
+	cli.Ui
+}
+
+func (quietUi) Output(string) {}
+func (quietUi) Info(string)   {}
+
+// logFormat is the value of the -log-format flag.
+type logFormat string
+
+func (f *logFormat) String() string {
+	return string(*f)
+}
+
+func (f *logFormat) Set(s string) error {
+	if s != "text" && s != "json" {
+		return fmt.Errorf("unknown log format %q, must be text or json", s)
+	}
+
+	*f = logFormat(s)
+	return nil
+}
+
+// GetLogger returns the logger selected by the log flags, writing to stderr.
+// Only warnings and errors are logged by default.
+func GetLogger() *logger {
+	level := levelWarn
+	switch {
+	case Quiet:
+		level = levelError
+	case Verbose:
+		level = levelDebug
+	}
+
+	return &logger{
+		mu:    &sync.Mutex{},
+		w:     os.Stderr,
+		level: level,
+		json:  LogFormat == "json",
+	}
+}
+
+// The levels of the logged events, as in log/slog.
+const (
+	levelDebug = -4
+	levelInfo  = 0
+	levelWarn  = 4
+	levelError = 8
+)
+
+var levelNames = map[int]string{
+	levelDebug: "DEBUG",
+	levelInfo:  "INFO",
+	levelWarn:  "WARN",
+	levelError: "ERROR",
+}
+
+// logger is a migrate.Logger writing the events at or above level to w, in
+// the text or JSON format of log/slog.
+type logger struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level int
+	json  bool
+	// args are the keys and values added to every event.
+	args []interface{}
+}
+
+var _ migrate.Logger = (*logger)(nil)
+
+// With returns a logger adding args to every event.
+func (l *logger) With(args ...interface{}) *logger {
+	with := *l
+	with.args = append(append([]interface{}{}, l.args...), args...)
+	return &with
+}
+
+func (l *logger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log(levelDebug, msg, args)
+}
+
+func (l *logger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log(levelInfo, msg, args)
+}
+
+func (l *logger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log(levelWarn, msg, args)
+}
+
+func (l *logger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log(levelError, msg, args)
+}
+
+func (l *logger) log(level int, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	fields := []interface{}{
+		"time", time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+		"level", levelNames[level],
+		"msg", msg,
+	}
+	fields = append(fields, l.args...)
+	fields = append(fields, args...)
+	if len(fields)%2 != 0 {
+		fields = append(fields[:len(fields)-1], "!BADKEY", fields[len(fields)-1])
+	}
+
+	var buf bytes.Buffer
+	if l.json {
+		buf.WriteByte('{')
+	}
+	for i := 0; i < len(fields); i += 2 {
+		key, value := fmt.Sprint(fields[i]), logValue(fields[i+1])
+		switch {
+		case l.json:
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			v, err := json.Marshal(value)
+			if err != nil {
+				v, _ = json.Marshal(fmt.Sprint(value))
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		default:
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(quoteLogText(key))
+			buf.WriteByte('=')
+			buf.WriteString(quoteLogText(fmt.Sprint(value)))
+		}
+	}
+	if l.json {
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+// logValue returns the value logged for v: errors and durations are logged
+// as text.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// quoteLogText quotes s if it isn't a single word.
+func quoteLogText(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"") || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
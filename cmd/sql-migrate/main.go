@@ -6,9 +6,12 @@ import (
 
 	"github.com/mitchellh/cli"
 
+	_ "github.com/shasderias/sql-migrate/pkg/db/mysql"
 	_ "github.com/shasderias/sql-migrate/pkg/db/postgres"
 )
 
+const version = "0.0.4"
+
 func main() {
 	os.Exit(realMain())
 }
@@ -39,9 +42,36 @@ func realMain() int {
 			"skip": func() (cli.Command, error) {
 				return &SkipCommand{}, nil
 			},
+			"baseline": func() (cli.Command, error) {
+				return &BaselineCommand{}, nil
+			},
+			"squash": func() (cli.Command, error) {
+				return &SquashCommand{}, nil
+			},
+			"goto": func() (cli.Command, error) {
+				return &GotoCommand{}, nil
+			},
+			"force": func() (cli.Command, error) {
+				return &ForceCommand{}, nil
+			},
+			"verify": func() (cli.Command, error) {
+				return &VerifyCommand{}, nil
+			},
+			"history": func() (cli.Command, error) {
+				return &HistoryCommand{}, nil
+			},
+			"lock": func() (cli.Command, error) {
+				return &LockCommand{}, nil
+			},
+			"lock status": func() (cli.Command, error) {
+				return &LockStatusCommand{}, nil
+			},
+			"lock force-unlock": func() (cli.Command, error) {
+				return &LockForceUnlockCommand{}, nil
+			},
 		},
 		HelpFunc: cli.BasicHelpFunc("sql-migrate"),
-		Version:  "0.0.4",
+		Version:  version,
 	}
 
 	exitCode, err := cli.Run()
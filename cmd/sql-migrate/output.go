@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/shasderias/sql-migrate/pkg/config"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFormat is the value of the -output flag.
+type outputFormat string
+
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+func (f *outputFormat) Set(s string) error {
+	switch s {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of table, json or yaml", s)
+	}
+
+	*f = outputFormat(s)
+	return nil
+}
+
+var OutputFormat = outputFormat(outputTable)
+
+// StructuredOutput reports whether a machine-readable output format was
+// selected.
+func StructuredOutput() bool {
+	return OutputFormat != outputTable
+}
+
+// SetupUi selects the ui for the output and log flags. It is called once the
+// flags of a command are parsed.
+func SetupUi() {
+	ui = &cli.BasicUi{Writer: os.Stdout}
+
+	// Keep stdout clean for the structured document, everything else goes
+	// to stderr.
+	if StructuredOutput() {
+		ui = &cli.BasicUi{Writer: os.Stdout, ErrorWriter: os.Stderr}
+	}
+
+	if Quiet {
+		ui = quietUi{ui}
+	}
+}
+
+// WriteOutput writes v to stdout in the selected structured output format.
+func WriteOutput(v interface{}) {
+	var (
+		b   []byte
+		err error
+	)
+
+	switch OutputFormat {
+	case outputJSON:
+		b, err = json.MarshalIndent(v, "", "  ")
+		b = append(b, '\n')
+	case outputYAML:
+		b, err = yaml.Marshal(v)
+	default:
+		panic("unreachable code reached")
+	}
+
+	if err != nil {
+		ui.Error(fmt.Sprintf("Could not encode output: %s", err))
+		return
+	}
+
+	_, _ = os.Stdout.Write(b)
+}
+
+// The exit codes of the commands, by class of error.
+const (
+	exitError              = 1
+	exitNoChange           = 2
+	exitLocked             = 3
+	exitDirty              = 4
+	exitChecksumMismatch   = 5
+	exitUnknownMigration   = 6
+	exitUnsupportedDialect = 7
+	exitConfig             = 8
+	exitAlreadyApplied     = 9
+)
+
+// ExitCode returns the exit code of a command that failed with err.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		return exitNoChange
+	case errors.Is(err, migrate.ErrLocked):
+		return exitLocked
+	case errors.Is(err, migrate.ErrDirty):
+		return exitDirty
+	case errors.Is(err, migrate.ErrChecksumMismatch):
+		return exitChecksumMismatch
+	case errors.Is(err, migrate.ErrUnknownMigration):
+		return exitUnknownMigration
+	case errors.Is(err, migrate.ErrUnsupportedDialect):
+		return exitUnsupportedDialect
+	case errors.Is(err, config.ErrNoEnvironment), errors.Is(err, config.ErrInvalid):
+		return exitConfig
+	case errors.Is(err, migrate.ErrAlreadyApplied):
+		return exitAlreadyApplied
+	default:
+		return exitError
+	}
+}
+
+// ReportError reports err, as a structured document if a structured output
+// format was selected, and returns the exit code of the command.
+func ReportError(err error) int {
+	if StructuredOutput() {
+		WriteOutput(&errorOutput{Error: err.Error()})
+	} else {
+		ui.Error(err.Error())
+	}
+
+	return ExitCode(err)
+}
+
+type errorOutput struct {
+	Error string `json:"error" yaml:"error"`
+}
+
+// migrationsOutput is the document written by the commands applying,
+// rolling back or skipping migrations.
+type migrationsOutput struct {
+	// Operation is the command that was run: up, down, redo, skip or goto.
+	Operation string `json:"operation" yaml:"operation"`
+	DryRun    bool   `json:"dry_run" yaml:"dry_run"`
+	// Count is the number of migrations applied, or planned on a dry run.
+	Count      int                `json:"count" yaml:"count"`
+	Migrations []*migrationOutput `json:"migrations" yaml:"migrations"`
+	Error      string             `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type migrationOutput struct {
+	ID        string `json:"id" yaml:"id"`
+	Direction string `json:"direction" yaml:"direction"`
+	// Queries are only included on a dry run.
+	Queries []string `json:"queries,omitempty" yaml:"queries,omitempty"`
+}
+
+func newMigrationsOutput(operation string, dryrun bool, migrations []*migrate.PlannedMigration, err error) *migrationsOutput {
+	out := &migrationsOutput{
+		Operation:  operation,
+		DryRun:     dryrun,
+		Count:      len(migrations),
+		Migrations: make([]*migrationOutput, 0, len(migrations)),
+	}
+
+	for _, m := range migrations {
+		mo := &migrationOutput{
+			ID:        m.ID,
+			Direction: m.Direction.String(),
+		}
+		if dryrun {
+			mo.Queries = m.Queries
+		}
+		out.Migrations = append(out.Migrations, mo)
+	}
+
+	if err != nil {
+		out.Error = err.Error()
+	}
+
+	return out
+}
+
+// tenantsOutput is the document written by the commands applying or rolling
+// back migrations in multi-tenant environments.
+type tenantsOutput struct {
+	Operation string          `json:"operation" yaml:"operation"`
+	DryRun    bool            `json:"dry_run" yaml:"dry_run"`
+	Succeeded int             `json:"succeeded" yaml:"succeeded"`
+	Failed    int             `json:"failed" yaml:"failed"`
+	Skipped   int             `json:"skipped" yaml:"skipped"`
+	Tenants   []*tenantOutput `json:"tenants" yaml:"tenants"`
+	Error     string          `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type tenantOutput struct {
+	Name string `json:"name" yaml:"name"`
+	// Status is ok, failed or skipped.
+	Status     string             `json:"status" yaml:"status"`
+	Count      int                `json:"count" yaml:"count"`
+	Migrations []*migrationOutput `json:"migrations" yaml:"migrations"`
+	DurationMS int64              `json:"duration_ms" yaml:"duration_ms"`
+	Error      string             `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func tenantStatus(result *migrate.TenantResult) string {
+	switch {
+	case result.Skipped:
+		return "skipped"
+	case result.Err != nil:
+		return "failed"
+	default:
+		return "ok"
+	}
+}
+
+func newTenantsOutput(operation string, dryrun bool, results []*migrate.TenantResult, plans map[*migrate.Tenant][]*migrate.PlannedMigration) *tenantsOutput {
+	out := &tenantsOutput{
+		Operation: operation,
+		DryRun:    dryrun,
+		Tenants:   make([]*tenantOutput, 0, len(results)),
+	}
+
+	for _, result := range results {
+		migrations := newMigrationsOutput(operation, dryrun, plans[result.Tenant], result.Err)
+
+		to := &tenantOutput{
+			Name:       result.Tenant.Name,
+			Status:     tenantStatus(result),
+			Count:      migrations.Count,
+			Migrations: migrations.Migrations,
+			DurationMS: result.Duration.Milliseconds(),
+			Error:      migrations.Error,
+		}
+
+		switch to.Status {
+		case "skipped":
+			out.Skipped++
+		case "failed":
+			out.Failed++
+		default:
+			out.Succeeded++
+		}
+
+		out.Tenants = append(out.Tenants, to)
+	}
+
+	return out
+}
+
+// statusOutput is the document written by the status command.
+type statusOutput struct {
+	Applied    int                      `json:"applied" yaml:"applied"`
+	Pending    int                      `json:"pending" yaml:"pending"`
+	Migrations []*migrationStatusOutput `json:"migrations" yaml:"migrations"`
+	// Unknown are the applied migrations that were not found.
+	Unknown []string `json:"unknown" yaml:"unknown"`
+}
+
+type migrationStatusOutput struct {
+	ID          string     `json:"id" yaml:"id"`
+	Applied     bool       `json:"applied" yaml:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty" yaml:"applied_at,omitempty"`
+	Dirty       bool       `json:"dirty" yaml:"dirty"`
+	Direction   string     `json:"direction,omitempty" yaml:"direction,omitempty"`
+	DurationMS  int64      `json:"duration_ms,omitempty" yaml:"duration_ms,omitempty"`
+	AppliedBy   string     `json:"applied_by,omitempty" yaml:"applied_by,omitempty"`
+	Hostname    string     `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	ToolVersion string     `json:"tool_version,omitempty" yaml:"tool_version,omitempty"`
+	Baseline    bool       `json:"baseline,omitempty" yaml:"baseline,omitempty"`
+}
+
+func newStatusOutput(migrations []*migrate.Migration, records []*migrate.Record) *statusOutput {
+	out := &statusOutput{
+		Migrations: make([]*migrationStatusOutput, 0, len(migrations)),
+		Unknown:    make([]string, 0),
+	}
+
+	byID := make(map[string]*migrate.Record)
+	for _, r := range records {
+		byID[r.ID] = r
+	}
+
+	known := make(map[string]bool)
+	for _, m := range migrations {
+		known[m.ID] = true
+
+		ms := &migrationStatusOutput{
+			ID: m.ID,
+		}
+
+		if r, ok := byID[m.ID]; ok {
+			appliedAt := r.AppliedAt
+			ms.Applied = true
+			ms.AppliedAt = &appliedAt
+			ms.Dirty = r.Dirty
+			ms.Direction = r.Direction.String()
+			ms.DurationMS = r.Duration.Milliseconds()
+			ms.AppliedBy = r.AppliedBy
+			ms.Hostname = r.Hostname
+			ms.ToolVersion = r.ToolVersion
+			ms.Baseline = r.Baseline
+			out.Applied++
+		} else {
+			out.Pending++
+		}
+
+		out.Migrations = append(out.Migrations, ms)
+	}
+
+	for _, r := range records {
+		if !known[r.ID] {
+			out.Unknown = append(out.Unknown, r.ID)
+		}
+	}
+
+	return out
+}
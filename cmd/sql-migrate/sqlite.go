@@ -0,0 +1,8 @@
+//go:build cgo
+// +build cgo
+
+package main
+
+// The sqlite3 dialect needs cgo, so binaries built with CGO_ENABLED=0 don't
+// support it.
+import _ "github.com/shasderias/sql-migrate/pkg/db/sqlite"
@@ -1,14 +1,22 @@
 module github.com/shasderias/sql-migrate
 
-go 1.12
+go 1.16
 
 require (
+	github.com/dolthub/go-mysql-server v0.11.0
+	github.com/go-sql-driver/mysql v1.6.0
 	github.com/jackc/pgconn v1.10.1
 	github.com/jackc/pgx/v4 v4.14.0
 	github.com/lib/pq v1.10.2
 	github.com/mattn/go-runewidth v0.0.4 // indirect
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/mitchellh/cli v1.0.0
 	github.com/olekukonko/tablewriter v0.0.1
-	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127
-	gopkg.in/yaml.v2 v2.2.2
+	github.com/prometheus/client_golang v1.3.0
+	github.com/prometheus/client_model v0.1.0
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15
+	gopkg.in/yaml.v2 v2.2.4
 )
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,11 +14,52 @@ const (
 	defaultTableName = "migration"
 )
 
+var (
+	// ErrNoEnvironment is returned for environments missing from the
+	// configuration file.
+	ErrNoEnvironment = errors.New("no environment")
+	// ErrInvalid is returned for environments missing required settings or
+	// with conflicting ones.
+	ErrInvalid = errors.New("invalid environment")
+)
+
 type Environment struct {
 	Dialect    string `yaml:"dialect"`
 	DataSource string `yaml:"datasource"`
 	Dir        string `yaml:"dir"`
 	TableName  string `yaml:"table"`
+	// Schema is the schema of the record table, created if missing.
+	Schema string `yaml:"schema"`
+	// SearchPath is the search_path of the migration sessions.
+	SearchPath string `yaml:"search_path"`
+	// History enables logging every operation to the history table.
+	History bool `yaml:"history"`
+	// Tenants enables the multi-tenant mode, in which the migrations are
+	// applied to every tenant.
+	Tenants *Tenants `yaml:"tenants"`
+	// Hooks lists SQL files run around migrations.
+	Hooks *Hooks `yaml:"hooks"`
+}
+
+// Hooks lists the SQL files run before and after applying or rolling back
+// migrations. The files have the format of migrations: their Up statements
+// are run around applying migrations, and their Down statements around
+// rolling back migrations. Relative paths are relative to the directory of
+// the configuration file.
+type Hooks struct {
+	Before []string `yaml:"before"`
+	After  []string `yaml:"after"`
+}
+
+// Tenants lists the tenants of a multi-tenant environment, either schemas of
+// the database of the environment or databases of their own.
+type Tenants struct {
+	// Query returns the schemas of the tenants, one per row.
+	Query string `yaml:"query"`
+	// Schemas lists the schemas of the tenants.
+	Schemas []string `yaml:"schemas"`
+	// DataSources lists the data sources of the tenants, by tenant name.
+	DataSources map[string]string `yaml:"datasources"`
 }
 
 func Get(filename, envName string) (*Environment, error) {
@@ -28,15 +70,26 @@ func Get(filename, envName string) (*Environment, error) {
 
 	env, ok := envs[envName]
 	if !ok {
-		return nil, fmt.Errorf("no environment named %s", envName)
+		return nil, fmt.Errorf("%w named %s", ErrNoEnvironment, envName)
 	}
 
 	if env.Dialect == "" {
-		return nil, errors.New("dialect not specified")
+		return nil, fmt.Errorf("%w: dialect not specified", ErrInvalid)
+	}
+
+	if env.Tenants != nil {
+		if err := env.Tenants.validate(); err != nil {
+			return nil, err
+		}
+		for name, ds := range env.Tenants.DataSources {
+			env.Tenants.DataSources[name] = os.ExpandEnv(ds)
+		}
 	}
 
-	if env.DataSource == "" {
-		return nil, errors.New("data source not specified")
+	// Tenants with data sources of their own don't need the data source of
+	// the environment.
+	if env.DataSource == "" && (env.Tenants == nil || len(env.Tenants.DataSources) == 0) {
+		return nil, fmt.Errorf("%w: data source not specified", ErrInvalid)
 	}
 	env.DataSource = os.ExpandEnv(env.DataSource)
 
@@ -44,6 +97,16 @@ func Get(filename, envName string) (*Environment, error) {
 		env.Dir = "migrations"
 	}
 
+	if env.Hooks != nil {
+		for _, paths := range [][]string{env.Hooks.Before, env.Hooks.After} {
+			for i, path := range paths {
+				if !filepath.IsAbs(path) {
+					paths[i] = filepath.Join(filepath.Dir(filename), path)
+				}
+			}
+		}
+	}
+
 	if env.TableName == "" {
 		env.TableName = defaultTableName
 	}
@@ -51,6 +114,25 @@ func Get(filename, envName string) (*Environment, error) {
 	return env, nil
 }
 
+func (t *Tenants) validate() error {
+	n := 0
+	if t.Query != "" {
+		n++
+	}
+	if len(t.Schemas) > 0 {
+		n++
+	}
+	if len(t.DataSources) > 0 {
+		n++
+	}
+
+	if n != 1 {
+		return fmt.Errorf("%w: tenants must specify exactly one of query, schemas or datasources", ErrInvalid)
+	}
+
+	return nil
+}
+
 func parseConfigFile(path string) (map[string]*Environment, error) {
 	file, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -61,7 +143,7 @@ func parseConfigFile(path string) (map[string]*Environment, error) {
 
 	err = yaml.Unmarshal(file, &envs)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
 	}
 
 	return envs, nil
@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+var _ migrate.Locker = DB{}
+
+// lockPollInterval is the time GET_LOCK waits for the lock before the context
+// is checked again.
+const lockPollInterval = 1 * time.Second
+
+// namedLock holds the connection on which the named lock was acquired with
+// GET_LOCK. The lock is released when that connection is closed, so it must
+// be kept out of the pool while the lock is held.
+type namedLock struct {
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// lockName derives the lock name from the database and record table names.
+// Named locks are server wide, and their names are limited to 64 characters,
+// so long names are hashed.
+func (db DB) lockName() string {
+	name := "sql-migrate:" + db.dbName + "." + db.TableName()
+	if len(name) <= 64 {
+		return name
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return fmt.Sprintf("sql-migrate:%x", h.Sum64())
+}
+
+// Lock acquires a named lock keyed on the database and record table names.
+func (db DB) Lock(ctx context.Context) error {
+	db.lock.mu.Lock()
+	defer db.lock.mu.Unlock()
+
+	if db.lock.conn != nil {
+		return fmt.Errorf("migration lock already held")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	// GET_LOCK can't be interrupted, so it is polled for the context to be
+	// honoured.
+	for {
+		var acquired sql.NullInt64
+		err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?);`,
+			db.lockName(), int(lockPollInterval/time.Second)).Scan(&acquired)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		if acquired.Valid && acquired.Int64 == 1 {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	db.lock.conn = conn
+
+	return nil
+}
+
+func (db DB) Unlock(ctx context.Context) error {
+	db.lock.mu.Lock()
+	defer db.lock.mu.Unlock()
+
+	conn := db.lock.conn
+	if conn == nil {
+		return nil
+	}
+	db.lock.conn = nil
+
+	if _, err := conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?);`, db.lockName()); err != nil {
+		// Closing the session releases the lock.
+		_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		conn.Close()
+		return err
+	}
+
+	return conn.Close()
+}
+
+// lockHolder returns the ID of the connection holding the lock, or 0 if it
+// isn't held.
+func (db DB) lockHolder(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT IS_USED_LOCK(?);`, db.lockName()).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id.Int64, nil
+}
+
+func (db DB) LockStatus(ctx context.Context) (*migrate.LockStatus, error) {
+	id, err := db.lockHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		return &migrate.LockStatus{}, nil
+	}
+
+	const stmt = `
+SELECT
+	coalesce(user, ''),
+	coalesce(host, '')
+FROM
+	information_schema.processlist
+WHERE
+	id = ?;`
+
+	var user, host string
+	err = db.QueryRowContext(ctx, stmt, id).Scan(&user, &host)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &migrate.LockStatus{
+		Locked: true,
+		Holder: formatHolder(id, user, host),
+	}, nil
+}
+
+func formatHolder(id int64, user, host string) string {
+	holder := fmt.Sprintf("connection %d", id)
+	if user != "" {
+		holder += " user " + user
+	}
+	if host != "" {
+		holder += " from " + host
+	}
+	return holder
+}
+
+// ForceUnlock kills the connection holding the named lock. Named locks can
+// only be released by the connection holding them, so this is the only way to
+// release a lock held by a stuck process.
+func (db DB) ForceUnlock(ctx context.Context) error {
+	id, err := db.lockHolder(ctx)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	_, err = db.DB.ExecContext(ctx, fmt.Sprintf("KILL %d;", id))
+	return err
+}
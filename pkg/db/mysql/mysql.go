@@ -0,0 +1,136 @@
+// Package mysql implements migrate.DB for MySQL and MariaDB, using the
+// github.com/go-sql-driver/mysql driver. Importing the package registers the
+// "mysql" dialect.
+//
+// MySQL implicitly commits the transaction in progress before and after most
+// DDL statements, such as CREATE TABLE or ALTER TABLE. A transactional
+// migration running DDL statements is therefore not atomic: if it fails
+// halfway, the statements executed before the failure are not rolled back.
+// A warning is logged through the Logger of the Migrator when a transactional
+// migration runs a DDL statement.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/shasderias/sql-migrate/pkg/db/sqldb"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func init() {
+	migrate.RegisterDB("mysql", DB{})
+}
+
+// Dialect is the sqldb.Dialect of MySQL. It doesn't enable parseTime, so DBs
+// created with sqldb.New must be opened with parseTime=true.
+var Dialect = &sqldb.Dialect{
+	DriverName:      "mysql",
+	Placeholder:     sqldb.QuestionPlaceholder,
+	QuoteIdentifier: sqldb.Backtick,
+	RecordTableDDL: `
+CREATE TABLE IF NOT EXISTS %s (
+	id           VARCHAR(255) NOT NULL PRIMARY KEY,
+	applied_at   DATETIME(6)  NOT NULL,
+	checksum     VARCHAR(64)  NOT NULL DEFAULT '',
+	dirty        BOOLEAN      NOT NULL DEFAULT false,
+	direction    VARCHAR(4)   NOT NULL DEFAULT 'up',
+	duration_ms  BIGINT       NOT NULL DEFAULT 0,
+	applied_by   VARCHAR(255) NOT NULL DEFAULT '',
+	hostname     VARCHAR(255) NOT NULL DEFAULT '',
+	tool_version VARCHAR(255) NOT NULL DEFAULT '',
+	baseline     BOOLEAN      NOT NULL DEFAULT false
+);`,
+	HistoryTableDDL: `
+CREATE TABLE IF NOT EXISTS %s (
+	id           BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	migration_id VARCHAR(255) NOT NULL,
+	operation    VARCHAR(16)  NOT NULL,
+	direction    VARCHAR(4)   NOT NULL,
+	started_at   DATETIME(6)  NOT NULL,
+	duration_ms  BIGINT       NOT NULL,
+	success      BOOLEAN      NOT NULL,
+	error        TEXT         NOT NULL,
+	applied_by   VARCHAR(255) NOT NULL DEFAULT '',
+	hostname     VARCHAR(255) NOT NULL DEFAULT '',
+	tool_version VARCHAR(255) NOT NULL DEFAULT ''
+);`,
+}
+
+type DB struct {
+	*sqldb.DB
+	dbName string
+	lock   *namedLock
+}
+
+// New connects to the database described by config.Datasource, a DSN of the
+// form [user[:password]@][net[(addr)]]/dbname[?param1=value1&paramN=valueN].
+// The parseTime parameter is always enabled, as the record table has DATETIME
+// columns. Schemas and search paths are not supported, the record table is
+// created in dbname.
+func (db DB) New(ctx context.Context, config *migrate.DBConfig) (migrate.DB, error) {
+	if err := sqldb.CheckConfig(config); err != nil {
+		return nil, err
+	}
+
+	cfg, err := mysql.ParseDSN(config.Datasource)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ParseTime = true
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := sql.OpenDB(connector)
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &DB{
+		DB:     sqldb.New(conn, Dialect, config.TableName),
+		dbName: cfg.DBName,
+		lock:   &namedLock{},
+	}, nil
+}
+
+// Tx warns about DDL statements.
+type Tx struct {
+	*sqldb.Tx
+	// warned is set once the DDL warning was reported for the transaction.
+	warned bool
+}
+
+func (db DB) Begin(ctx context.Context) (migrate.Tx, error) {
+	tx, err := db.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx.(*sqldb.Tx)}, nil
+}
+
+func (tx *Tx) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	if !tx.warned && isDDL(sql) {
+		migrate.LoggerFromContext(ctx).WarnContext(ctx, "MySQL implicitly commits DDL statements, the transaction is not atomic",
+			"statement", strings.TrimSpace(sql))
+		tx.warned = true
+	}
+
+	return tx.Tx.Exec(ctx, sql, arguments...)
+}
+
+// ddlRegexp matches the statements causing an implicit commit, skipping any
+// leading whitespace and comments.
+var ddlRegexp = regexp.MustCompile(`(?is)^(\s+|--[^\n]*(\n|$)|#[^\n]*(\n|$)|/\*.*?\*/)*(CREATE|ALTER|DROP|RENAME|TRUNCATE)\b`)
+
+func isDDL(stmt string) bool {
+	return ddlRegexp.MatchString(stmt)
+}
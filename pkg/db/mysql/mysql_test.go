@@ -0,0 +1,232 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/auth"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	. "gopkg.in/check.v1"
+
+	"github.com/shasderias/sql-migrate/pkg/db/sqldb"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int primary key)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_insert",
+		Up:   []string{"INSERT INTO people (id) VALUES (1)"},
+		Down: []string{"DELETE FROM people WHERE id = 1"},
+	},
+}
+
+// MysqlSuite runs the driver against an in-process MySQL-compatible server.
+type MysqlSuite struct {
+	server     *server.Server
+	datasource string
+	migrator   *migrate.Migrator
+	source     *migrate.MemorySource
+}
+
+var _ = Suite(&MysqlSuite{})
+
+func (s *MysqlSuite) SetUpTest(c *C) {
+	engine := sqle.NewDefault(memory.NewMemoryDBProvider(memory.NewDatabase("test")))
+
+	var err error
+	s.server, err = server.NewDefaultServer(server.Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewNativeSingle("root", "", auth.AllPermissions),
+	}, engine)
+	c.Assert(err, IsNil)
+	go s.server.Start()
+
+	// No parseTime, it must be enabled by the driver.
+	s.datasource = fmt.Sprintf("root@tcp(%s)/test", s.server.Listener.Addr())
+	s.setUpMigrator(c)
+}
+
+func (s *MysqlSuite) setUpMigrator(c *C) {
+	var err error
+	s.migrator, err = migrate.New("mysql", s.datasource, "migrations")
+	c.Assert(err, IsNil)
+
+	s.source = &migrate.MemorySource{Migrations: migrations}
+}
+
+func (s *MysqlSuite) TearDownTest(c *C) {
+	s.migrator.Close()
+	s.server.Close()
+}
+
+// ServerSuite runs the tests of MysqlSuite against the MySQL server of the
+// data source in $MYSQL_DATASOURCE, e.g. "root@tcp(127.0.0.1)/test", as the
+// in-process server doesn't support everything MySQL does. It is skipped if
+// unset. The tables of the tests are dropped from the database.
+type ServerSuite struct {
+	MysqlSuite
+}
+
+var _ = Suite(&ServerSuite{})
+
+func (s *ServerSuite) SetUpSuite(c *C) {
+	s.datasource = os.Getenv("MYSQL_DATASOURCE")
+	if s.datasource == "" {
+		c.Skip("$MYSQL_DATASOURCE not set")
+	}
+}
+
+func (s *ServerSuite) SetUpTest(c *C) {
+	s.setUpMigrator(c)
+}
+
+func (s *ServerSuite) TearDownTest(c *C) {
+	for _, table := range []string{"people", "migrations", "migrations_history"} {
+		_, err := s.migrator.DB.Exec(context.Background(), "DROP TABLE IF EXISTS "+table)
+		c.Check(err, IsNil)
+	}
+	s.migrator.Close()
+}
+
+func (s *MysqlSuite) TestRunMigrations(c *C) {
+	ctx := context.Background()
+
+	n, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err := s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+	c.Assert(records[0].AppliedAt.IsZero(), Equals, false)
+	c.Assert(records[0].Checksum, Equals, migrations[0].Checksum())
+	c.Assert(records[0].Direction, Equals, migrate.Up)
+
+	n, err = s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 0)
+
+	n, err = s.migrator.Exec(s.source, migrate.Down)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err = s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
+
+func (s *MysqlSuite) TestUpdateRecord(c *C) {
+	ctx := context.Background()
+
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+
+	records, err := s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+
+	record := records[1]
+	record.Dirty = true
+	record.Duration = 1500 * time.Millisecond
+	c.Assert(s.migrator.DB.UpdateRecord(ctx, record), IsNil)
+
+	records, err = s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records[1].Dirty, Equals, true)
+	c.Assert(records[1].Duration, Equals, 1500*time.Millisecond)
+	c.Assert(records[0].Dirty, Equals, false)
+}
+
+func (s *MysqlSuite) TestHistory(c *C) {
+	ctx := context.Background()
+	c.Assert(s.migrator.EnableHistory(ctx), IsNil)
+
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+
+	entries, err := s.migrator.HistoryEntries(ctx, &migrate.HistoryFilter{Limit: 1})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].ID, Equals, int64(2))
+	c.Assert(entries[0].MigrationID, Equals, "2_insert")
+	c.Assert(entries[0].Operation, Equals, migrate.OperationUp)
+	c.Assert(entries[0].StartedAt.IsZero(), Equals, false)
+}
+
+func (s *MysqlSuite) TestLock(c *C) {
+	ctx := context.Background()
+
+	other, err := DB{}.New(ctx, &migrate.DBConfig{Datasource: s.datasource, TableName: "migrations"})
+	c.Assert(err, IsNil)
+	defer other.Close()
+
+	c.Assert(s.migrator.Locker.Lock(ctx), IsNil)
+	c.Assert(s.migrator.Locker.Unlock(ctx), IsNil)
+
+	c.Assert(other.(migrate.Locker).Lock(ctx), IsNil)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	c.Assert(s.migrator.Locker.Lock(timeoutCtx), Equals, context.DeadlineExceeded)
+
+	c.Assert(other.(migrate.Locker).Unlock(ctx), IsNil)
+}
+
+func (s *MysqlSuite) TestLockStatus(c *C) {
+	if s.server != nil {
+		c.Skip("information_schema.processlist isn't supported by the in-process server")
+	}
+
+	ctx := context.Background()
+
+	other, err := DB{}.New(ctx, &migrate.DBConfig{Datasource: s.datasource, TableName: "migrations"})
+	c.Assert(err, IsNil)
+	defer other.Close()
+
+	c.Assert(s.migrator.Locker.Lock(ctx), IsNil)
+
+	status, err := other.(migrate.Locker).LockStatus(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(status.Locked, Equals, true)
+	c.Assert(status.Holder, Matches, "connection [0-9]+.*")
+
+	c.Assert(s.migrator.Locker.Unlock(ctx), IsNil)
+
+	status, err = other.(migrate.Locker).LockStatus(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(status.Locked, Equals, false)
+}
+
+// OfflineSuite tests the parts of the driver not needing a server.
+type OfflineSuite struct{}
+
+var _ = Suite(&OfflineSuite{})
+
+func (s *OfflineSuite) TestLockName(c *C) {
+	db := DB{DB: sqldb.New(nil, Dialect, "migrations"), dbName: "test"}
+	c.Assert(db.lockName(), Equals, "sql-migrate:test.migrations")
+
+	db.DB = sqldb.New(nil, Dialect, "a_very_long_record_table_name_exceeding_the_length_limit")
+	c.Assert(len(db.lockName()) <= 64, Equals, true)
+}
+
+func (s *OfflineSuite) TestIsDDL(c *C) {
+	c.Assert(isDDL("CREATE TABLE people (id int)"), Equals, true)
+	c.Assert(isDDL("\n-- comment\n  alter table people add column name text"), Equals, true)
+	c.Assert(isDDL("/* comment */ DROP TABLE people"), Equals, true)
+	c.Assert(isDDL("INSERT INTO people (id) VALUES (1)"), Equals, false)
+	c.Assert(isDDL("-- CREATE TABLE people\nSELECT 1"), Equals, false)
+}
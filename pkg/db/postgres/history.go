@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+var _ migrate.HistoryStore = DB{}
+
+func (db DB) historyTableName() string {
+	return qualifiedName(db.Schema, db.tableName+"_history")
+}
+
+func (db DB) CreateHistoryTable(ctx context.Context) error {
+	const stmt = `
+CREATE TABLE IF NOT EXISTS %s (
+	id           BIGSERIAL   PRIMARY KEY,
+	migration_id TEXT        NOT NULL,
+	operation    TEXT        NOT NULL,
+	direction    TEXT        NOT NULL,
+	started_at   TIMESTAMPTZ NOT NULL,
+	duration_ms  BIGINT      NOT NULL,
+	success      BOOLEAN     NOT NULL,
+	error        TEXT        NOT NULL DEFAULT '',
+	applied_by   TEXT        NOT NULL DEFAULT '',
+	hostname     TEXT        NOT NULL DEFAULT '',
+	tool_version TEXT        NOT NULL DEFAULT ''
+);`
+
+	_, err := db.Exec(ctx, fmt.Sprintf(stmt, db.historyTableName()))
+	return err
+}
+
+func (db DB) InsertHistory(ctx context.Context, entry *migrate.HistoryEntry) error {
+	const stmt = `
+INSERT INTO %s
+	(migration_id, operation, direction, started_at, duration_ms, success, error, applied_by, hostname, tool_version)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id;`
+
+	return db.QueryRow(ctx, fmt.Sprintf(stmt, db.historyTableName()),
+		entry.MigrationID,
+		string(entry.Operation),
+		entry.Direction.String(),
+		entry.StartedAt,
+		entry.Duration.Milliseconds(),
+		entry.Success,
+		entry.Error,
+		entry.AppliedBy,
+		entry.Hostname,
+		entry.ToolVersion,
+	).Scan(&entry.ID)
+}
+
+func (db DB) History(ctx context.Context, filter *migrate.HistoryFilter) ([]*migrate.HistoryEntry, error) {
+	const stmt = `
+SELECT
+	id, migration_id, operation, direction, started_at, duration_ms, success, error, applied_by, hostname, tool_version
+FROM
+	%s
+%s
+ORDER BY id DESC
+%s;`
+
+	var (
+		conds []string
+		args  []interface{}
+		limit string
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter != nil {
+		if filter.MigrationID != "" {
+			conds = append(conds, "migration_id = "+arg(filter.MigrationID))
+		}
+		if filter.Operation != "" {
+			conds = append(conds, "operation = "+arg(string(filter.Operation)))
+		}
+		if !filter.Since.IsZero() {
+			conds = append(conds, "started_at >= "+arg(filter.Since))
+		}
+		if !filter.Until.IsZero() {
+			conds = append(conds, "started_at < "+arg(filter.Until))
+		}
+		if filter.FailedOnly {
+			conds = append(conds, "NOT success")
+		}
+		if filter.Limit > 0 {
+			limit = "LIMIT " + arg(filter.Limit)
+		}
+	}
+
+	var where string
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	rows, err := db.Query(ctx, fmt.Sprintf(stmt, db.historyTableName(), where, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*migrate.HistoryEntry
+	for rows.Next() {
+		var (
+			entry      migrate.HistoryEntry
+			operation  string
+			direction  string
+			durationMS int64
+		)
+		err := rows.Scan(&entry.ID, &entry.MigrationID, &operation, &direction, &entry.StartedAt,
+			&durationMS, &entry.Success, &entry.Error, &entry.AppliedBy, &entry.Hostname, &entry.ToolVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Operation = migrate.Operation(operation)
+		entry.Direction, err = migrate.ParseDirection(direction)
+		if err != nil {
+			return nil, err
+		}
+		entry.Duration = time.Duration(durationMS) * time.Millisecond
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+var _ migrate.Locker = DB{}
+
+// advisoryLock holds the connection on which the session level advisory
+// lock was acquired. The lock is released when that connection is closed, so
+// it must be kept out of the pool while the lock is held.
+type advisoryLock struct {
+	mu   sync.Mutex
+	held bool
+	// conn is nil if the DB was created with FromConn, in which case the lock
+	// is held by the connection of the DB.
+	conn *pgxpool.Conn
+}
+
+// lockKey derives the advisory lock key from the record table name, so that
+// migrations against different record tables don't block each other.
+func (db DB) lockKey() int64 {
+	name := db.tableName
+	if db.Schema != "" {
+		name = db.Schema + "." + name
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("sql-migrate:" + name))
+	return int64(h.Sum64() >> 1)
+}
+
+// Lock acquires a session level advisory lock keyed on the record table name.
+func (db DB) Lock(ctx context.Context) error {
+	db.lock.mu.Lock()
+	defer db.lock.mu.Unlock()
+
+	if db.lock.held {
+		return fmt.Errorf("migration lock already held")
+	}
+
+	if db.pool == nil {
+		if _, err := db.conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, db.lockKey()); err != nil {
+			return err
+		}
+
+		db.lock.held = true
+
+		return nil
+	}
+
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, db.lockKey()); err != nil {
+		conn.Release()
+		return err
+	}
+
+	db.lock.held = true
+	db.lock.conn = conn
+
+	return nil
+}
+
+func (db DB) Unlock(ctx context.Context) error {
+	db.lock.mu.Lock()
+	defer db.lock.mu.Unlock()
+
+	if !db.lock.held {
+		return nil
+	}
+
+	conn := db.lock.conn
+	db.lock.held = false
+	db.lock.conn = nil
+
+	if conn == nil {
+		_, err := db.conn.Exec(ctx, `SELECT pg_advisory_unlock($1);`, db.lockKey())
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1);`, db.lockKey()); err != nil {
+		// Closing the session releases the lock.
+		_ = conn.Conn().Close(ctx)
+		conn.Release()
+		return err
+	}
+
+	conn.Release()
+
+	return nil
+}
+
+// A bigint advisory lock key shows up in pg_locks split into classid (high
+// 32 bits) and objid (low 32 bits), with objsubid 1.
+const lockHoldersQuery = `
+SELECT
+	l.pid,
+	coalesce(a.usename, ''),
+	coalesce(host(a.client_addr), ''),
+	coalesce(a.application_name, '')
+FROM
+	pg_locks l
+	LEFT JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE
+	l.locktype = 'advisory' AND
+	l.granted AND
+	l.classid::bigint = $1 AND
+	l.objid::bigint = $2 AND
+	l.objsubid = 1;`
+
+func (db DB) LockStatus(ctx context.Context) (*migrate.LockStatus, error) {
+	key := db.lockKey()
+
+	rows, err := db.Query(ctx, lockHoldersQuery, key>>32, key&0xffffffff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holders []string
+	for rows.Next() {
+		var (
+			pid                 int32
+			user, addr, appName string
+		)
+		if err := rows.Scan(&pid, &user, &addr, &appName); err != nil {
+			return nil, err
+		}
+		holders = append(holders, formatHolder(pid, user, addr, appName))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &migrate.LockStatus{
+		Locked: len(holders) > 0,
+		Holder: strings.Join(holders, ", "),
+	}, nil
+}
+
+func formatHolder(pid int32, user, addr, appName string) string {
+	holder := fmt.Sprintf("pid %d", pid)
+	if user != "" {
+		holder += " user " + user
+	}
+	if addr != "" {
+		holder += " from " + addr
+	}
+	if appName != "" {
+		holder += " (" + appName + ")"
+	}
+	return holder
+}
+
+// ForceUnlock terminates the sessions holding the advisory lock. Advisory
+// locks can only be released by the session holding them, so this is the only
+// way to release a lock held by a stuck process.
+func (db DB) ForceUnlock(ctx context.Context) error {
+	const stmt = `
+SELECT
+	pg_terminate_backend(pid)
+FROM
+	pg_locks
+WHERE
+	locktype = 'advisory' AND
+	granted AND
+	classid::bigint = $1 AND
+	objid::bigint = $2 AND
+	objsubid = 1;`
+
+	key := db.lockKey()
+
+	_, err := db.Exec(ctx, stmt, key>>32, key&0xffffffff)
+	return err
+}
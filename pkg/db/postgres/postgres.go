@@ -3,10 +3,11 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 
 	"github.com/shasderias/sql-migrate/pkg/migrate"
 )
@@ -15,75 +16,241 @@ func init() {
 	migrate.RegisterDB("postgres", DB{})
 }
 
+// conn is implemented by *pgxpool.Pool and *pgx.Conn.
+type conn interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
 type DB struct {
-	*pgxpool.Pool
+	conn
+	// pool is nil if the DB was created with FromConn.
+	pool *pgxpool.Pool
+	// Schema is the schema of the record table, created if missing. If
+	// empty, the record table is created in the default schema of the
+	// session. It must be set before creating the Migrator.
+	Schema    string
 	tableName string
+	lock      *advisoryLock
+	// owned is set if the DB connected to the database itself, in which case
+	// Close closes the connection.
+	owned bool
 }
 
-func (db DB) New(connString, tableName string) (migrate.DB, error) {
-	conn, err := pgxpool.Connect(context.Background(), connString)
+// New connects to the database described by config.Datasource. If set,
+// config.SearchPath is the search_path of every connection of the pool.
+func (db DB) New(ctx context.Context, config *migrate.DBConfig) (migrate.DB, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.Datasource)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.SearchPath != "" {
+		poolConfig.ConnConfig.RuntimeParams["search_path"] = config.SearchPath
+	}
+
+	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	d := FromPool(pool, config.TableName)
+	d.Schema = config.Schema
+	d.owned = true
+
+	return d, nil
+}
+
+// FromPool returns a DB keeping its records in tableName, which executes the
+// migrations on connections of pool. Closing the DB doesn't close pool. The
+// record table is created in the default schema, unless DB.Schema is set.
+//
+// Use migrate.NewFromDB to create a Migrator from the DB.
+func FromPool(pool *pgxpool.Pool, tableName string) *DB {
+	return &DB{
+		conn:      pool,
+		pool:      pool,
+		tableName: tableName,
+		lock:      &advisoryLock{},
+	}
+}
+
+// FromConn returns a DB keeping its records in tableName, which executes the
+// migrations on conn. Closing the DB doesn't close conn. As with FromPool,
+// DB.Schema sets the schema of the record table.
+//
+// conn must not be used concurrently while migrating, as a *pgx.Conn isn't
+// safe for concurrent use. The migration lock is held by conn itself.
+//
+// Use migrate.NewFromDB to create a Migrator from the DB.
+func FromConn(conn *pgx.Conn, tableName string) *DB {
 	return &DB{
-		Pool:      conn,
+		conn:      conn,
 		tableName: tableName,
-	}, nil
+		lock:      &advisoryLock{},
+	}
 }
 
-func (db DB) CreateRecordTable() error {
+func (db DB) CreateRecordTable(ctx context.Context) error {
+	if db.Schema != "" {
+		stmt := fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s;`, pgx.Identifier{db.Schema}.Sanitize())
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
 	const stmt = `
 CREATE TABLE IF NOT EXISTS %s (
-	id         TEXT        PRIMARY KEY,
-	applied_at TIMESTAMPTZ NOT NULL
+	id           TEXT        PRIMARY KEY,
+	applied_at   TIMESTAMPTZ NOT NULL,
+	checksum     TEXT        NOT NULL DEFAULT '',
+	dirty        BOOLEAN     NOT NULL DEFAULT false,
+	direction    TEXT        NOT NULL DEFAULT 'up',
+	duration_ms  BIGINT      NOT NULL DEFAULT 0,
+	applied_by   TEXT        NOT NULL DEFAULT '',
+	hostname     TEXT        NOT NULL DEFAULT '',
+	tool_version TEXT        NOT NULL DEFAULT '',
+	baseline     BOOLEAN     NOT NULL DEFAULT false
 );`
 
-	_, err := db.Exec(context.Background(), db.escapeTableName(stmt))
+	if _, err := db.Exec(ctx, db.escapeTableName(stmt)); err != nil {
+		return err
+	}
+
+	return db.upgradeRecordTable(ctx)
+}
+
+// upgradeRecordTable adds the columns introduced after the record table was
+// first created.
+func (db DB) upgradeRecordTable(ctx context.Context) error {
+	const stmt = `
+ALTER TABLE %s
+	ADD COLUMN IF NOT EXISTS checksum     TEXT    NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS dirty        BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS direction    TEXT    NOT NULL DEFAULT 'up',
+	ADD COLUMN IF NOT EXISTS duration_ms  BIGINT  NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS applied_by   TEXT    NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS hostname     TEXT    NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS tool_version TEXT    NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS baseline     BOOLEAN NOT NULL DEFAULT false;`
+
+	_, err := db.Exec(ctx, db.escapeTableName(stmt))
 	return err
 }
 
-func (db DB) Records() ([]*migrate.Record, error) {
+func (db DB) Records(ctx context.Context) ([]*migrate.Record, error) {
 	const stmt = `
 SELECT
-	*
+	id, applied_at, checksum, dirty, direction, duration_ms, applied_by, hostname, tool_version, baseline
 FROM 
 	%s
 ORDER BY id ASC;`
 
 	var records []*migrate.Record
 
-	rows, err := db.Query(context.Background(), db.escapeTableName(stmt))
+	rows, err := db.Query(ctx, db.escapeTableName(stmt))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var record migrate.Record
-		err := rows.Scan(&record.ID, &record.AppliedAt)
+		var (
+			record     migrate.Record
+			direction  string
+			durationMS int64
+		)
+		err := rows.Scan(&record.ID, &record.AppliedAt, &record.Checksum, &record.Dirty,
+			&direction, &durationMS, &record.AppliedBy, &record.Hostname, &record.ToolVersion,
+			&record.Baseline)
 		if err != nil {
 			return nil, err
 		}
+
+		record.Direction, err = migrate.ParseDirection(direction)
+		if err != nil {
+			return nil, err
+		}
+		record.Duration = time.Duration(durationMS) * time.Millisecond
+
 		records = append(records, &record)
 	}
 
-	return records, nil
+	return records, rows.Err()
 }
 
 const (
-	insertRecordStmt = `INSERT INTO %s (id, applied_at) VALUES ($1, $2);`
+	insertRecordStmt = `
+INSERT INTO %s
+	(id, applied_at, checksum, dirty, direction, duration_ms, applied_by, hostname, tool_version, baseline)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+	updateRecordStmt = `
+UPDATE %s SET
+	applied_at = $2, checksum = $3, dirty = $4, direction = $5, duration_ms = $6,
+	applied_by = $7, hostname = $8, tool_version = $9, baseline = $10
+WHERE id = $1;`
 	deleteRecordStmt = `DELETE FROM %s WHERE id = $1;`
 )
 
-func (db DB) InsertRecord(record *migrate.Record) error {
-	_, err := db.Exec(context.Background(), db.escapeTableName(insertRecordStmt),
-		record.ID, record.AppliedAt)
+// recordArgs returns the arguments of insertRecordStmt and updateRecordStmt.
+func recordArgs(record *migrate.Record) []interface{} {
+	return []interface{}{
+		record.ID,
+		record.AppliedAt,
+		record.Checksum,
+		record.Dirty,
+		record.Direction.String(),
+		record.Duration.Milliseconds(),
+		record.AppliedBy,
+		record.Hostname,
+		record.ToolVersion,
+		record.Baseline,
+	}
+}
+
+var _ migrate.StringQuerier = DB{}
+
+func (db DB) QueryStrings(ctx context.Context, query string) ([]string, error) {
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var strs []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+
+	return strs, rows.Err()
+}
+
+func (db DB) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	tag, err := db.conn.Exec(ctx, sql, arguments...)
+	return tag, wrapError(err)
+}
+
+func (db DB) InsertRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := db.Exec(ctx, db.escapeTableName(insertRecordStmt), recordArgs(record)...)
 
 	return err
 }
 
-func (db DB) DeleteRecord(record *migrate.Record) error {
-	_, err := db.Exec(context.Background(), db.escapeTableName(deleteRecordStmt),
+func (db DB) UpdateRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := db.Exec(ctx, db.escapeTableName(updateRecordStmt), recordArgs(record)...)
+
+	return err
+}
+
+func (db DB) DeleteRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := db.Exec(ctx, db.escapeTableName(deleteRecordStmt),
 		record.ID)
 
 	return err
@@ -91,11 +258,12 @@ func (db DB) DeleteRecord(record *migrate.Record) error {
 
 type Tx struct {
 	pgx.Tx
+	schema    string
 	tableName string
 }
 
-func (db DB) Begin() (migrate.Tx, error) {
-	tx, err := db.BeginTx(context.Background(), pgx.TxOptions{
+func (db DB) Begin(ctx context.Context) (migrate.Tx, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.Serializable,
 	})
 	if err != nil {
@@ -104,40 +272,83 @@ func (db DB) Begin() (migrate.Tx, error) {
 
 	return &Tx{
 		Tx:        tx,
+		schema:    db.Schema,
 		tableName: db.tableName,
 	}, nil
 }
 
 func (db DB) escapeTableName(stmt string) string {
-	return fmt.Sprintf(stmt, pgx.Identifier{db.tableName}.Sanitize())
+	return fmt.Sprintf(stmt, qualifiedName(db.Schema, db.tableName))
 }
 
+// Close closes the connection pool, unless the DB was created with FromPool or
+// FromConn.
 func (db DB) Close() {
-	db.Pool.Close()
+	if db.owned {
+		db.pool.Close()
+	}
 }
 
-func (tx Tx) InsertRecord(record *migrate.Record) error {
-	_, err := tx.Exec(context.Background(), tx.escapeTableName(insertRecordStmt),
-		record.ID, record.AppliedAt)
+func (tx Tx) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	tag, err := tx.Tx.Exec(ctx, sql, arguments...)
+	return tag, wrapError(err)
+}
+
+func (tx Tx) InsertRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := tx.Exec(ctx, tx.escapeTableName(insertRecordStmt), recordArgs(record)...)
 
 	return err
 }
 
-func (tx Tx) DeleteRecord(record *migrate.Record) error {
-	_, err := tx.Exec(context.Background(), tx.escapeTableName(deleteRecordStmt),
+func (tx Tx) UpdateRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := tx.Exec(ctx, tx.escapeTableName(updateRecordStmt), recordArgs(record)...)
+
+	return err
+}
+
+func (tx Tx) DeleteRecord(ctx context.Context, record *migrate.Record) error {
+	_, err := tx.Exec(ctx, tx.escapeTableName(deleteRecordStmt),
 		record.ID)
 
 	return err
 }
 
 func (tx Tx) escapeTableName(stmt string) string {
-	return fmt.Sprintf(stmt, pq.QuoteIdentifier(tx.tableName))
+	return fmt.Sprintf(stmt, qualifiedName(tx.schema, tx.tableName))
+}
+
+// qualifiedName returns the quoted name of table in schema, or of table
+// alone if schema is empty.
+func qualifiedName(schema, table string) string {
+	if schema == "" {
+		return pgx.Identifier{table}.Sanitize()
+	}
+	return pgx.Identifier{schema, table}.Sanitize()
+}
+
+// pgError is a *pgconn.PgError reporting its position in the failed statement
+// as a migrate.PositionError.
+type pgError struct {
+	err *pgconn.PgError
+}
+
+var _ migrate.PositionError = pgError{}
+
+func wrapError(err error) error {
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Position > 0 {
+		return pgError{err: pgErr}
+	}
+	return err
+}
+
+func (e pgError) Error() string {
+	return e.err.Error()
 }
 
-func (tx Tx) Commit() error {
-	return tx.Tx.Commit(context.Background())
+func (e pgError) Unwrap() error {
+	return e.err
 }
 
-func (tx Tx) Rollback() error {
-	return tx.Tx.Rollback(context.Background())
+func (e pgError) Position() int {
+	return int(e.err.Position)
 }
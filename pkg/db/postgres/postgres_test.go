@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	. "gopkg.in/check.v1"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int primary key)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_insert",
+		Up:   []string{"INSERT INTO people (id) VALUES (1)"},
+		Down: []string{"DELETE FROM people WHERE id = 1"},
+	},
+}
+
+// testSchema is the schema created by the tests of the schema handling.
+const testSchema = "sql_migrate_test"
+
+// PostgresSuite runs the driver against the PostgreSQL server of the data
+// source in $POSTGRES_DATASOURCE, e.g. "dbname=test sslmode=disable". It is
+// skipped if unset. The tables and schema of the tests are dropped from the
+// database.
+type PostgresSuite struct {
+	datasource string
+	source     *migrate.MemorySource
+}
+
+var _ = Suite(&PostgresSuite{})
+
+func (s *PostgresSuite) SetUpSuite(c *C) {
+	s.datasource = os.Getenv("POSTGRES_DATASOURCE")
+	if s.datasource == "" {
+		c.Skip("$POSTGRES_DATASOURCE not set")
+	}
+}
+
+func (s *PostgresSuite) SetUpTest(c *C) {
+	s.source = &migrate.MemorySource{Migrations: migrations}
+}
+
+func (s *PostgresSuite) TearDownTest(c *C) {
+	conn, err := pgx.Connect(context.Background(), s.datasource)
+	c.Assert(err, IsNil)
+	defer conn.Close(context.Background())
+
+	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS people, migrations, migrations_history",
+		"DROP SCHEMA IF EXISTS " + testSchema + " CASCADE",
+	} {
+		_, err := conn.Exec(context.Background(), stmt)
+		c.Check(err, IsNil)
+	}
+}
+
+// runMigrations applies and rolls back the migrations with migrator.
+func (s *PostgresSuite) runMigrations(c *C, migrator *migrate.Migrator) {
+	ctx := context.Background()
+
+	n, err := migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err := migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+	c.Assert(records[0].Checksum, Equals, migrations[0].Checksum())
+
+	n, err = migrator.Exec(s.source, migrate.Down)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err = migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
+
+func (s *PostgresSuite) TestNew(c *C) {
+	migrator, err := migrate.New("postgres", s.datasource, "migrations")
+	c.Assert(err, IsNil)
+
+	s.runMigrations(c, migrator)
+
+	// The pool was created by New, so closing the Migrator closes it.
+	pool := migrator.DB.(*DB).pool
+	migrator.Close()
+	c.Assert(pool.Ping(context.Background()), NotNil)
+}
+
+func (s *PostgresSuite) TestFromPool(c *C) {
+	ctx := context.Background()
+
+	pool, err := pgxpool.Connect(ctx, s.datasource)
+	c.Assert(err, IsNil)
+	defer pool.Close()
+
+	migrator, err := migrate.NewFromDB(ctx, FromPool(pool, "migrations"))
+	c.Assert(err, IsNil)
+
+	s.runMigrations(c, migrator)
+
+	migrator.Close()
+	c.Assert(pool.Ping(ctx), IsNil)
+}
+
+func (s *PostgresSuite) TestFromConn(c *C) {
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, s.datasource)
+	c.Assert(err, IsNil)
+	defer conn.Close(ctx)
+
+	migrator, err := migrate.NewFromDB(ctx, FromConn(conn, "migrations"))
+	c.Assert(err, IsNil)
+
+	s.runMigrations(c, migrator)
+
+	migrator.Close()
+	c.Assert(conn.Ping(ctx), IsNil)
+}
+
+// tableExists reports whether the record table exists in schema.
+func (s *PostgresSuite) tableExists(c *C, schema string) bool {
+	conn, err := pgx.Connect(context.Background(), s.datasource)
+	c.Assert(err, IsNil)
+	defer conn.Close(context.Background())
+
+	var exists bool
+	err = conn.QueryRow(context.Background(),
+		"SELECT to_regclass($1) IS NOT NULL", qualifiedName(schema, "migrations")).Scan(&exists)
+	c.Assert(err, IsNil)
+	return exists
+}
+
+func (s *PostgresSuite) TestSchema(c *C) {
+	migrator, err := migrate.NewFromConfig(context.Background(), "postgres", &migrate.DBConfig{
+		Datasource: s.datasource,
+		TableName:  "migrations",
+		Schema:     testSchema,
+	})
+	c.Assert(err, IsNil)
+	defer migrator.Close()
+
+	c.Assert(s.tableExists(c, testSchema), Equals, true)
+	c.Assert(s.tableExists(c, ""), Equals, false)
+
+	s.runMigrations(c, migrator)
+}
+
+func (s *PostgresSuite) TestFromPoolSchema(c *C) {
+	ctx := context.Background()
+
+	pool, err := pgxpool.Connect(ctx, s.datasource)
+	c.Assert(err, IsNil)
+	defer pool.Close()
+
+	db := FromPool(pool, "migrations")
+	db.Schema = testSchema
+
+	migrator, err := migrate.NewFromDB(ctx, db)
+	c.Assert(err, IsNil)
+	defer migrator.Close()
+
+	c.Assert(s.tableExists(c, testSchema), Equals, true)
+
+	s.runMigrations(c, migrator)
+}
+
+// OfflineSuite tests the parts of the driver not needing a server.
+type OfflineSuite struct{}
+
+var _ = Suite(&OfflineSuite{})
+
+func (s *OfflineSuite) TestQualifiedName(c *C) {
+	c.Assert(qualifiedName("", "migrations"), Equals, `"migrations"`)
+	c.Assert(qualifiedName("app", "migrations"), Equals, `"app"."migrations"`)
+
+	db := FromPool(nil, "migrations")
+	c.Assert(db.historyTableName(), Equals, `"migrations_history"`)
+	db.Schema = "app"
+	c.Assert(db.historyTableName(), Equals, `"app"."migrations_history"`)
+}
+
+func (s *OfflineSuite) TestLockKey(c *C) {
+	db := FromPool(nil, "migrations")
+	key := db.lockKey()
+	c.Assert(key >= 0, Equals, true)
+
+	db.Schema = "app"
+	c.Assert(db.lockKey(), Not(Equals), key)
+}
+
+func (s *OfflineSuite) TestWrapError(c *C) {
+	pgErr := &pgconn.PgError{Message: "syntax error", Position: 12}
+	err := wrapError(pgErr)
+	c.Assert(err, FitsTypeOf, pgError{})
+	c.Assert(err.(migrate.PositionError).Position(), Equals, 12)
+	c.Assert(errors.Is(err, pgErr), Equals, true)
+
+	pgErr = &pgconn.PgError{Message: "deadlock detected"}
+	c.Assert(wrapError(pgErr), Equals, error(pgErr))
+}
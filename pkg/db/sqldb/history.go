@@ -0,0 +1,137 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+var _ migrate.HistoryStore = DB{}
+
+func (db DB) historyTableName() string {
+	return db.Dialect.QuoteIdentifier(db.tableName + "_history")
+}
+
+func (db DB) CreateHistoryTable(ctx context.Context) error {
+	ddl := db.Dialect.HistoryTableDDL
+	if ddl == "" {
+		ddl = DefaultHistoryTableDDL
+	}
+
+	_, err := db.DB.ExecContext(ctx, fmt.Sprintf(ddl, db.historyTableName()))
+	return err
+}
+
+// InsertHistory sets the ID of entry if the driver reports the IDs of
+// inserted rows.
+func (db DB) InsertHistory(ctx context.Context, entry *migrate.HistoryEntry) error {
+	const stmt = `
+INSERT INTO %s
+	(migration_id, operation, direction, started_at, duration_ms, success, error, applied_by, hostname, tool_version)
+VALUES
+	(%s, %s, %s, %s, %s, %s, %s, %s, %s, %s);`
+
+	res, err := db.DB.ExecContext(ctx, format(stmt, db.Dialect, db.tableName+"_history", 10),
+		entry.MigrationID,
+		string(entry.Operation),
+		entry.Direction.String(),
+		entry.StartedAt,
+		entry.Duration.Milliseconds(),
+		entry.Success,
+		entry.Error,
+		entry.AppliedBy,
+		entry.Hostname,
+		entry.ToolVersion,
+	)
+	if err != nil {
+		return err
+	}
+
+	if id, err := res.LastInsertId(); err == nil {
+		entry.ID = id
+	}
+
+	return nil
+}
+
+func (db DB) History(ctx context.Context, filter *migrate.HistoryFilter) ([]*migrate.HistoryEntry, error) {
+	const stmt = `
+SELECT
+	id, migration_id, operation, direction, started_at, duration_ms, success, error, applied_by, hostname, tool_version
+FROM
+	%s
+%s
+ORDER BY id DESC
+%s;`
+
+	var (
+		conds []string
+		args  []interface{}
+		limit string
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return db.Dialect.Placeholder(len(args))
+	}
+
+	if filter != nil {
+		if filter.MigrationID != "" {
+			conds = append(conds, "migration_id = "+arg(filter.MigrationID))
+		}
+		if filter.Operation != "" {
+			conds = append(conds, "operation = "+arg(string(filter.Operation)))
+		}
+		if !filter.Since.IsZero() {
+			conds = append(conds, "started_at >= "+arg(filter.Since))
+		}
+		if !filter.Until.IsZero() {
+			conds = append(conds, "started_at < "+arg(filter.Until))
+		}
+		if filter.FailedOnly {
+			conds = append(conds, "NOT success")
+		}
+		if filter.Limit > 0 {
+			limit = "LIMIT " + arg(filter.Limit)
+		}
+	}
+
+	var where string
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(stmt, db.historyTableName(), where, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*migrate.HistoryEntry
+	for rows.Next() {
+		var (
+			entry      migrate.HistoryEntry
+			operation  string
+			direction  string
+			durationMS int64
+		)
+		err := rows.Scan(&entry.ID, &entry.MigrationID, &operation, &direction, &entry.StartedAt,
+			&durationMS, &entry.Success, &entry.Error, &entry.AppliedBy, &entry.Hostname, &entry.ToolVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Operation = migrate.Operation(operation)
+		entry.Direction, err = migrate.ParseDirection(direction)
+		if err != nil {
+			return nil, err
+		}
+		entry.Duration = time.Duration(durationMS) * time.Millisecond
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
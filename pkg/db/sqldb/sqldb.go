@@ -0,0 +1,395 @@
+// Package sqldb implements migrate.DB on top of database/sql, so that
+// migrations can be run with any database/sql driver. The differences between
+// databases are described by a Dialect.
+//
+// A Dialect is registered with migrate.RegisterDB using Register, after which
+// the Migrator connects to the database itself:
+//
+//	sqldb.Register("duckdb", &sqldb.Dialect{
+//		DriverName:      "duckdb",
+//		Placeholder:     sqldb.QuestionPlaceholder,
+//		QuoteIdentifier: sqldb.DoubleQuote,
+//	})
+//
+//	migrator, err := migrate.New("duckdb", "test.db", "migrations")
+//
+// An existing *sql.DB is wrapped with New instead:
+//
+//	migrator, err := migrate.NewFromDB(ctx, sqldb.New(db, dialect, "migrations"))
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+// Dialect describes how to talk to a database through database/sql.
+type Dialect struct {
+	// DriverName is the name of the database/sql driver, as passed to
+	// sql.Open.
+	DriverName string
+	// Placeholder returns the placeholder of the n-th argument of a
+	// statement, counting from 1.
+	Placeholder func(n int) string
+	// QuoteIdentifier quotes a table name.
+	QuoteIdentifier func(name string) string
+	// RecordTableDDL creates the record table, unless it exists. The %s verb
+	// is replaced with the quoted table name. Defaults to
+	// DefaultRecordTableDDL.
+	RecordTableDDL string
+	// HistoryTableDDL creates the history table, unless it exists. The %s
+	// verb is replaced with the quoted table name. Defaults to
+	// DefaultHistoryTableDDL.
+	HistoryTableDDL string
+	// Open, if set, opens the database instead of sql.Open, for dialects
+	// needing to tweak the data source or the connection pool.
+	Open func(datasource string) (*sql.DB, error)
+}
+
+// DefaultRecordTableDDL creates the record table using the standard SQL
+// types.
+const DefaultRecordTableDDL = `
+CREATE TABLE IF NOT EXISTS %s (
+	id           VARCHAR(255) NOT NULL PRIMARY KEY,
+	applied_at   TIMESTAMP    NOT NULL,
+	checksum     VARCHAR(64)  NOT NULL DEFAULT '',
+	dirty        BOOLEAN      NOT NULL DEFAULT false,
+	direction    VARCHAR(4)   NOT NULL DEFAULT 'up',
+	duration_ms  BIGINT       NOT NULL DEFAULT 0,
+	applied_by   VARCHAR(255) NOT NULL DEFAULT '',
+	hostname     VARCHAR(255) NOT NULL DEFAULT '',
+	tool_version VARCHAR(255) NOT NULL DEFAULT '',
+	baseline     BOOLEAN      NOT NULL DEFAULT false
+);`
+
+// DefaultHistoryTableDDL creates the history table using the standard SQL
+// types and identity column.
+const DefaultHistoryTableDDL = `
+CREATE TABLE IF NOT EXISTS %s (
+	id           BIGINT       GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	migration_id VARCHAR(255) NOT NULL,
+	operation    VARCHAR(16)  NOT NULL,
+	direction    VARCHAR(4)   NOT NULL,
+	started_at   TIMESTAMP    NOT NULL,
+	duration_ms  BIGINT       NOT NULL,
+	success      BOOLEAN      NOT NULL,
+	error        TEXT         NOT NULL DEFAULT '',
+	applied_by   VARCHAR(255) NOT NULL DEFAULT '',
+	hostname     VARCHAR(255) NOT NULL DEFAULT '',
+	tool_version VARCHAR(255) NOT NULL DEFAULT ''
+);`
+
+// QuestionPlaceholder returns ?, as used by MySQL and SQLite.
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+// DollarPlaceholder returns $n, as used by PostgreSQL.
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// DoubleQuote quotes name with double quotes, as in standard SQL.
+func DoubleQuote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Backtick quotes name with backticks, as in MySQL.
+func Backtick(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Register registers dialect with migrate.RegisterDB under name.
+func Register(name string, dialect *Dialect) {
+	migrate.RegisterDB(name, &DB{Dialect: dialect})
+}
+
+type DB struct {
+	*sql.DB
+	Dialect   *Dialect
+	tableName string
+}
+
+// New returns a DB keeping its records in tableName of db.
+func New(db *sql.DB, dialect *Dialect, tableName string) *DB {
+	return &DB{
+		DB:        db,
+		Dialect:   dialect,
+		tableName: tableName,
+	}
+}
+
+// New connects to config.Datasource using the dialect of db. Schemas and
+// search paths are not supported.
+func (db DB) New(ctx context.Context, config *migrate.DBConfig) (migrate.DB, error) {
+	if err := CheckConfig(config); err != nil {
+		return nil, err
+	}
+
+	var (
+		conn *sql.DB
+		err  error
+	)
+	if db.Dialect.Open != nil {
+		conn, err = db.Dialect.Open(config.Datasource)
+	} else {
+		conn, err = sql.Open(db.Dialect.DriverName, config.Datasource)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return New(conn, db.Dialect, config.TableName), nil
+}
+
+// CheckConfig returns an error if config sets a field not supported by the
+// DBs of this package.
+func CheckConfig(config *migrate.DBConfig) error {
+	if config.Schema != "" {
+		return fmt.Errorf("schema not supported")
+	}
+	if config.SearchPath != "" {
+		return fmt.Errorf("search path not supported")
+	}
+	return nil
+}
+
+// TableName returns the name of the record table.
+func (db DB) TableName() string {
+	return db.tableName
+}
+
+func (db DB) CreateRecordTable(ctx context.Context) error {
+	ddl := db.Dialect.RecordTableDDL
+	if ddl == "" {
+		ddl = DefaultRecordTableDDL
+	}
+
+	_, err := db.DB.ExecContext(ctx, fmt.Sprintf(ddl, db.Dialect.QuoteIdentifier(db.tableName)))
+	return err
+}
+
+func (db DB) Records(ctx context.Context) ([]*migrate.Record, error) {
+	const stmt = `
+SELECT
+	id, applied_at, checksum, dirty, direction, duration_ms, applied_by, hostname, tool_version, baseline
+FROM
+	%s
+ORDER BY id ASC;`
+
+	var records []*migrate.Record
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(stmt, db.Dialect.QuoteIdentifier(db.tableName)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record     migrate.Record
+			direction  string
+			durationMS int64
+		)
+		err := rows.Scan(&record.ID, &record.AppliedAt, &record.Checksum, &record.Dirty,
+			&direction, &durationMS, &record.AppliedBy, &record.Hostname, &record.ToolVersion,
+			&record.Baseline)
+		if err != nil {
+			return nil, err
+		}
+
+		record.Direction, err = migrate.ParseDirection(direction)
+		if err != nil {
+			return nil, err
+		}
+		record.Duration = time.Duration(durationMS) * time.Millisecond
+
+		records = append(records, &record)
+	}
+
+	return records, rows.Err()
+}
+
+var _ migrate.StringQuerier = DB{}
+
+func (db DB) QueryStrings(ctx context.Context, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var strs []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+
+	return strs, rows.Err()
+}
+
+func (db DB) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	return execResult(db.DB.ExecContext(ctx, sql, arguments...))
+}
+
+func (db DB) InsertRecord(ctx context.Context, record *migrate.Record) error {
+	return insertRecord(ctx, db.DB, db.Dialect, db.tableName, record)
+}
+
+func (db DB) UpdateRecord(ctx context.Context, record *migrate.Record) error {
+	return updateRecord(ctx, db.DB, db.Dialect, db.tableName, record)
+}
+
+func (db DB) DeleteRecord(ctx context.Context, record *migrate.Record) error {
+	return deleteRecord(ctx, db.DB, db.Dialect, db.tableName, record)
+}
+
+type Tx struct {
+	*sql.Tx
+	dialect   *Dialect
+	tableName string
+}
+
+// Begin returns a *Tx.
+func (db DB) Begin(ctx context.Context) (migrate.Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{
+		Tx:        tx,
+		dialect:   db.Dialect,
+		tableName: db.tableName,
+	}, nil
+}
+
+func (db DB) Close() {
+	db.DB.Close()
+}
+
+func (tx Tx) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	return execResult(tx.Tx.ExecContext(ctx, sql, arguments...))
+}
+
+func (tx Tx) InsertRecord(ctx context.Context, record *migrate.Record) error {
+	return insertRecord(ctx, tx.Tx, tx.dialect, tx.tableName, record)
+}
+
+func (tx Tx) UpdateRecord(ctx context.Context, record *migrate.Record) error {
+	return updateRecord(ctx, tx.Tx, tx.dialect, tx.tableName, record)
+}
+
+func (tx Tx) DeleteRecord(ctx context.Context, record *migrate.Record) error {
+	return deleteRecord(ctx, tx.Tx, tx.dialect, tx.tableName, record)
+}
+
+func (tx Tx) Commit(ctx context.Context) error {
+	return tx.Tx.Commit()
+}
+
+func (tx Tx) Rollback(ctx context.Context) error {
+	return tx.Tx.Rollback()
+}
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertRecord(ctx context.Context, ex execer, dialect *Dialect, tableName string, record *migrate.Record) error {
+	const stmt = `
+INSERT INTO %s
+	(id, applied_at, checksum, dirty, direction, duration_ms, applied_by, hostname, tool_version, baseline)
+VALUES
+	(%s, %s, %s, %s, %s, %s, %s, %s, %s, %s);`
+
+	_, err := ex.ExecContext(ctx, format(stmt, dialect, tableName, 10),
+		record.ID,
+		record.AppliedAt,
+		record.Checksum,
+		record.Dirty,
+		record.Direction.String(),
+		record.Duration.Milliseconds(),
+		record.AppliedBy,
+		record.Hostname,
+		record.ToolVersion,
+		record.Baseline,
+	)
+
+	return err
+}
+
+func updateRecord(ctx context.Context, ex execer, dialect *Dialect, tableName string, record *migrate.Record) error {
+	const stmt = `
+UPDATE %s SET
+	applied_at = %s, checksum = %s, dirty = %s, direction = %s, duration_ms = %s,
+	applied_by = %s, hostname = %s, tool_version = %s, baseline = %s
+WHERE id = %s;`
+
+	_, err := ex.ExecContext(ctx, format(stmt, dialect, tableName, 10),
+		record.AppliedAt,
+		record.Checksum,
+		record.Dirty,
+		record.Direction.String(),
+		record.Duration.Milliseconds(),
+		record.AppliedBy,
+		record.Hostname,
+		record.ToolVersion,
+		record.Baseline,
+		record.ID,
+	)
+
+	return err
+}
+
+func deleteRecord(ctx context.Context, ex execer, dialect *Dialect, tableName string, record *migrate.Record) error {
+	const stmt = `DELETE FROM %s WHERE id = %s;`
+
+	_, err := ex.ExecContext(ctx, format(stmt, dialect, tableName, 1),
+		record.ID)
+
+	return err
+}
+
+// format replaces the verbs of stmt with the quoted table name followed by
+// the placeholders of n arguments.
+func format(stmt string, dialect *Dialect, tableName string, n int) string {
+	args := []interface{}{dialect.QuoteIdentifier(tableName)}
+	for i := 1; i <= n; i++ {
+		args = append(args, dialect.Placeholder(i))
+	}
+
+	return fmt.Sprintf(stmt, args...)
+}
+
+// result is the migrate.ExecResult of a statement.
+type result int64
+
+func (r result) RowsAffected() int64 {
+	return int64(r)
+}
+
+func execResult(res sql.Result, err error) (migrate.ExecResult, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	// Not every driver reports the number of affected rows.
+	n, _ := res.RowsAffected()
+
+	return result(n), nil
+}
@@ -0,0 +1,124 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	. "gopkg.in/check.v1"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+// dialect exercises the numbered placeholders, which SQLite supports as well.
+var dialect = &Dialect{
+	DriverName:      "sqlite3",
+	Placeholder:     DollarPlaceholder,
+	QuoteIdentifier: DoubleQuote,
+}
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_insert",
+		Up:   []string{"INSERT INTO people (id) VALUES (1)"},
+		Down: []string{"DELETE FROM people WHERE id = 1"},
+	},
+}
+
+type SqldbSuite struct {
+	datasource string
+}
+
+var _ = Suite(&SqldbSuite{})
+
+func (s *SqldbSuite) SetUpTest(c *C) {
+	s.datasource = filepath.Join(c.MkDir(), "test.db")
+}
+
+func (s *SqldbSuite) TestRegister(c *C) {
+	Register("sqldb-test", dialect)
+
+	migrator, err := migrate.New("sqldb-test", s.datasource, "my \"migrations\"")
+	c.Assert(err, IsNil)
+	defer migrator.Close()
+
+	s.runMigrations(c, migrator)
+}
+
+func (s *SqldbSuite) TestNewFromDB(c *C) {
+	db, err := sql.Open("sqlite3", s.datasource)
+	c.Assert(err, IsNil)
+
+	migrator, err := migrate.NewFromDB(context.Background(), New(db, dialect, "migrations"))
+	c.Assert(err, IsNil)
+	defer migrator.Close()
+
+	s.runMigrations(c, migrator)
+}
+
+func (s *SqldbSuite) runMigrations(c *C, migrator *migrate.Migrator) {
+	ctx := context.Background()
+	source := &migrate.MemorySource{Migrations: migrations}
+
+	n, err := migrator.Exec(source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err := migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[1].ID, Equals, "2_insert")
+	c.Assert(records[1].Direction, Equals, migrate.Up)
+
+	res, err := migrator.DB.Exec(ctx, "UPDATE people SET id = 2")
+	c.Assert(err, IsNil)
+	c.Assert(res.RowsAffected(), Equals, int64(1))
+
+	records[1].Dirty = true
+	records[1].Baseline = true
+	c.Assert(migrator.DB.UpdateRecord(ctx, records[1]), IsNil)
+
+	records, err = migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records[1].Dirty, Equals, true)
+	c.Assert(records[1].Baseline, Equals, true)
+	c.Assert(records[0].Baseline, Equals, false)
+
+	c.Assert(migrator.DB.DeleteRecord(ctx, records[1]), IsNil)
+
+	records, err = migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+}
+
+func (s *SqldbSuite) TestUnsupportedConfig(c *C) {
+	Register("sqldb-test", dialect)
+
+	_, err := migrate.NewFromConfig(context.Background(), "sqldb-test", &migrate.DBConfig{
+		Datasource: s.datasource,
+		TableName:  "migrations",
+		Schema:     "meta",
+	})
+	c.Assert(err, ErrorMatches, ".*schema not supported")
+}
+
+func (s *SqldbSuite) TestDiscoverSchemaTenants(c *C) {
+	Register("sqldb-test", dialect)
+
+	config := &migrate.DBConfig{Datasource: s.datasource, TableName: "migrations"}
+	tenants, err := migrate.DiscoverSchemaTenants(context.Background(), "sqldb-test", config,
+		"SELECT 'acme' UNION ALL SELECT 'globex'")
+	c.Assert(err, IsNil)
+	c.Assert(tenants, HasLen, 2)
+	c.Assert(tenants[0].Name, Equals, "acme")
+	c.Assert(tenants[1].Config.Schema, Equals, "globex")
+}
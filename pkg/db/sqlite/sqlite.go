@@ -0,0 +1,67 @@
+// Package sqlite implements migrate.DB for SQLite, using the cgo based
+// github.com/mattn/go-sqlite3 driver. Importing the package registers the
+// "sqlite3" dialect.
+//
+// SQLite has no advisory locks, so migrations of a database are not guarded
+// against concurrent runs of the migrator.
+package sqlite
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/shasderias/sql-migrate/pkg/db/sqldb"
+)
+
+func init() {
+	sqldb.Register("sqlite3", Dialect)
+}
+
+// Dialect is the sqldb.Dialect of SQLite.
+var Dialect = &sqldb.Dialect{
+	DriverName:      "sqlite3",
+	Placeholder:     sqldb.QuestionPlaceholder,
+	QuoteIdentifier: sqldb.DoubleQuote,
+	RecordTableDDL: `
+CREATE TABLE IF NOT EXISTS %s (
+	id           TEXT      PRIMARY KEY,
+	applied_at   TIMESTAMP NOT NULL,
+	checksum     TEXT      NOT NULL DEFAULT '',
+	dirty        BOOLEAN   NOT NULL DEFAULT false,
+	direction    TEXT      NOT NULL DEFAULT 'up',
+	duration_ms  BIGINT    NOT NULL DEFAULT 0,
+	applied_by   TEXT      NOT NULL DEFAULT '',
+	hostname     TEXT      NOT NULL DEFAULT '',
+	tool_version TEXT      NOT NULL DEFAULT '',
+	baseline     BOOLEAN   NOT NULL DEFAULT false
+);`,
+	HistoryTableDDL: `
+CREATE TABLE IF NOT EXISTS %s (
+	id           INTEGER   PRIMARY KEY,
+	migration_id TEXT      NOT NULL,
+	operation    TEXT      NOT NULL,
+	direction    TEXT      NOT NULL,
+	started_at   TIMESTAMP NOT NULL,
+	duration_ms  BIGINT    NOT NULL,
+	success      BOOLEAN   NOT NULL,
+	error        TEXT      NOT NULL DEFAULT '',
+	applied_by   TEXT      NOT NULL DEFAULT '',
+	hostname     TEXT      NOT NULL DEFAULT '',
+	tool_version TEXT      NOT NULL DEFAULT ''
+);`,
+	Open: open,
+}
+
+func open(datasource string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", datasource)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows a single writer at a time, and every connection to
+	// an in-memory database opens a database of its own.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
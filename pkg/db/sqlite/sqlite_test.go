@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/shasderias/sql-migrate/pkg/db/sqldb"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_alter_table",
+		Up:   []string{"ALTER TABLE people ADD COLUMN first_name text"},
+		Down: []string{"SELECT 0"}, // SQLite didn't support DROP COLUMN until 3.35
+	},
+}
+
+type SqliteSuite struct {
+	migrator *migrate.Migrator
+	source   *migrate.MemorySource
+}
+
+var _ = Suite(&SqliteSuite{})
+
+func (s *SqliteSuite) SetUpTest(c *C) {
+	var err error
+	s.migrator, err = migrate.New("sqlite3", filepath.Join(c.MkDir(), "test.db"), "migrations")
+	c.Assert(err, IsNil)
+
+	s.source = &migrate.MemorySource{Migrations: migrations}
+}
+
+func (s *SqliteSuite) TearDownTest(c *C) {
+	s.migrator.Close()
+}
+
+func (s *SqliteSuite) TestRunMigrations(c *C) {
+	ctx := context.Background()
+
+	n, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	_, err = s.migrator.DB.Exec(ctx, "INSERT INTO people (id, first_name) VALUES (1, 'Alice')")
+	c.Assert(err, IsNil)
+
+	records, err := s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+	c.Assert(records[0].AppliedAt.IsZero(), Equals, false)
+	c.Assert(records[0].Checksum, Equals, migrations[0].Checksum())
+	c.Assert(records[0].Direction, Equals, migrate.Up)
+
+	n, err = s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 0)
+
+	n, err = s.migrator.Exec(s.source, migrate.Down)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err = s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
+
+func (s *SqliteSuite) TestRollbackOnError(c *C) {
+	s.source.Migrations = append(s.source.Migrations, &migrate.Migration{
+		ID: "3_broken",
+		Up: []string{
+			"INSERT INTO people (id) VALUES (1)",
+			"INSERT INTO nonexistent (id) VALUES (1)",
+		},
+	})
+
+	n, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, NotNil)
+	c.Assert(n, Equals, 2)
+
+	ctx := context.Background()
+
+	var count int
+	err = s.migrator.DB.(*sqldb.DB).QueryRowContext(ctx, "SELECT COUNT(*) FROM people").Scan(&count)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 0)
+
+	records, err := s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+}
+
+func (s *SqliteSuite) TestUpdateRecord(c *C) {
+	ctx := context.Background()
+
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+
+	records, err := s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+
+	record := records[1]
+	record.Dirty = true
+	c.Assert(s.migrator.DB.UpdateRecord(ctx, record), IsNil)
+
+	records, err = s.migrator.DB.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records[1].Dirty, Equals, true)
+	c.Assert(records[0].Dirty, Equals, false)
+}
+
+func (s *SqliteSuite) TestHistory(c *C) {
+	ctx := context.Background()
+	c.Assert(s.migrator.EnableHistory(ctx), IsNil)
+
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	_, err = s.migrator.ExecMax(s.source, migrate.Down, 1)
+	c.Assert(err, IsNil)
+
+	entries, err := s.migrator.HistoryEntries(ctx, nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Assert(entries[0].ID, Equals, int64(3))
+	c.Assert(entries[0].MigrationID, Equals, "2_alter_table")
+	c.Assert(entries[0].Operation, Equals, migrate.OperationDown)
+	c.Assert(entries[0].Direction, Equals, migrate.Down)
+	c.Assert(entries[0].Success, Equals, true)
+	c.Assert(entries[0].StartedAt.IsZero(), Equals, false)
+
+	entries, err = s.migrator.HistoryEntries(ctx, &migrate.HistoryFilter{
+		MigrationID: "2_alter_table",
+		Operation:   migrate.OperationUp,
+		Since:       time.Now().Add(-time.Hour),
+		Limit:       1,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Assert(entries[0].ID, Equals, int64(2))
+
+	entries, err = s.migrator.HistoryEntries(ctx, &migrate.HistoryFilter{FailedOnly: true})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 0)
+}
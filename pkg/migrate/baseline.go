@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Baseline marks the migrations of src up to and including the one with the
+// given ID as applied, without executing them, for adopting sql-migrate on a
+// database whose schema already includes them. Their records have Baseline
+// set. Repeatable migrations are left to be applied by the next Exec.
+//
+// Baseline refuses to run if any migration was applied already, unless force
+// is set, in which case the existing records are left as they are.
+//
+// Returns the number of migrations marked as applied.
+func (m *Migrator) Baseline(src Source, id string, force bool) (int, error) {
+	return m.BaselineContext(context.Background(), src, id, force)
+}
+
+// BaselineContext is like Baseline, but uses ctx for all database operations.
+func (m *Migrator) BaselineContext(ctx context.Context, src Source, id string, force bool) (int, error) {
+	migrations, err := m.BaselineMigrationsContext(ctx, src, id, force)
+	return len(migrations), err
+}
+
+// BaselineMigrationsContext is like BaselineContext, but returns the
+// migrations marked as applied instead of their number.
+func (m *Migrator) BaselineMigrationsContext(ctx context.Context, src Source, id string, force bool) ([]*PlannedMigration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	migrations, err := m.PlanBaselineContext(ctx, src, id, force)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, nil
+	}
+
+	// All or nothing, so the baseline can simply be run again on failure.
+	start := time.Now()
+	err = m.insertBaseline(ctx, migrations)
+
+	var historyErr error
+	for _, migration := range migrations {
+		if logErr := m.logHistory(ctx, OperationBaseline, migration, start, err); logErr != nil && historyErr == nil {
+			historyErr = logErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations, historyErr
+}
+
+// PlanBaselineContext returns the migrations BaselineContext would mark as
+// applied.
+func (m *Migrator) PlanBaselineContext(ctx context.Context, src Source, id string, force bool) ([]*PlannedMigration, error) {
+	found, err := src.Find()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := m.DB.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records = ResolveSquashedRecords(found, records)
+
+	if len(records) > 0 && !force {
+		return nil, fmt.Errorf("cannot baseline: %w", ErrAlreadyApplied)
+	}
+
+	recorded := make(map[string]bool)
+	for _, record := range records {
+		recorded[record.ID] = true
+	}
+
+	var migrations []*Migration
+	for _, migration := range found {
+		if migration.ID == id && migration.Repeatable {
+			return nil, fmt.Errorf("cannot baseline repeatable migration %s", id)
+		}
+		if !migration.Repeatable {
+			migrations = append(migrations, migration)
+		}
+	}
+	sort.Sort(byID(migrations))
+
+	target := -1
+	for i, migration := range migrations {
+		if migration.ID == id {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMigration, id)
+	}
+
+	var result []*PlannedMigration
+	for _, migration := range migrations[:target+1] {
+		if !recorded[migration.ID] {
+			result = append(result, newPlannedMigration(migration, Up))
+		}
+	}
+
+	return result, nil
+}
+
+// insertBaseline inserts the baseline records of migrations in a single
+// transaction.
+func (m *Migrator) insertBaseline(ctx context.Context, migrations []*PlannedMigration) error {
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		record := m.newRecord(migration, 0)
+		record.Baseline = true
+		if err := tx.InsertRecord(ctx, record); err != nil {
+			rollback(tx)
+			return newTxError(migration, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	return nil
+}
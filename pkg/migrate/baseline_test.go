@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type BaselineSuite struct {
+	db       *memDB
+	history  *memHistory
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&BaselineSuite{})
+
+func (s *BaselineSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.history = &memHistory{}
+	s.migrator = &Migrator{DB: s.db, History: s.history}
+	s.source = &MemorySource{Migrations: append(sqlMigrations[:3:3], &Migration{
+		ID:         "people_view",
+		Up:         []string{"CREATE VIEW v1"},
+		Repeatable: true,
+	})}
+}
+
+func (s *BaselineSuite) TestBaseline(c *C) {
+	ctx := context.Background()
+
+	n, err := s.migrator.Baseline(s.source, "2_alter_table", false)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.db.log, HasLen, 0)
+
+	records, err := s.db.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	for _, record := range records {
+		c.Assert(record.Baseline, Equals, true)
+		c.Assert(record.Direction, Equals, Up)
+	}
+	c.Assert(historyLog(s.history.entries), DeepEquals, []string{
+		"1_create_table baseline up ok",
+		"2_alter_table baseline up ok",
+	})
+
+	plan, err := s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index up", "people_view up"})
+
+	n, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err = s.db.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records[2].ID, Equals, "3_add_index")
+	c.Assert(records[2].Baseline, Equals, false)
+}
+
+func (s *BaselineSuite) TestRefusedIfApplied(c *C) {
+	_, err := s.migrator.ExecMax(s.source, Up, 1)
+	c.Assert(err, IsNil)
+
+	_, err = s.migrator.Baseline(s.source, "2_alter_table", false)
+	c.Assert(err, ErrorMatches, "cannot baseline: migrations were applied already")
+	c.Assert(errors.Is(err, ErrAlreadyApplied), Equals, true)
+
+	n, err := s.migrator.Baseline(s.source, "2_alter_table", true)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Baseline, Equals, false)
+	c.Assert(records[1].Baseline, Equals, true)
+}
+
+func (s *BaselineSuite) TestUnknownMigration(c *C) {
+	_, err := s.migrator.Baseline(s.source, "4_missing", false)
+	c.Assert(err, ErrorMatches, "unknown migration: 4_missing")
+	c.Assert(errors.Is(err, ErrUnknownMigration), Equals, true)
+
+	_, err = s.migrator.Baseline(s.source, "people_view", false)
+	c.Assert(err, ErrorMatches, "cannot baseline repeatable migration people_view")
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
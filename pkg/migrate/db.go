@@ -4,33 +4,91 @@ import (
 	"context"
 	"fmt"
 	"time"
-
-	"github.com/jackc/pgconn"
 )
 
 type Record struct {
 	ID        string    `db:"id"`
 	AppliedAt time.Time `db:"applied_at"`
+	// Checksum is the Checksum of the migration when it was applied. It is
+	// empty for records created before checksums were tracked.
+	Checksum string `db:"checksum"`
+	// Dirty is set while a migration is executed without a transaction, and
+	// remains set if it fails halfway.
+	Dirty bool `db:"dirty"`
+
+	// Direction is the direction the migration was last executed in. It is
+	// only ever Down for a migration that is dirty while being rolled back.
+	Direction Direction `db:"direction"`
+	// Duration is how long executing the migration took.
+	Duration time.Duration `db:"duration_ms"`
+	// AppliedBy and Hostname identify the user and host the migration was
+	// executed by and on.
+	AppliedBy string `db:"applied_by"`
+	Hostname  string `db:"hostname"`
+	// ToolVersion is the Migrator.ToolVersion of the Migrator that executed
+	// the migration.
+	ToolVersion string `db:"tool_version"`
+	// Baseline is set for the records created by Migrator.Baseline, whose
+	// migrations were not executed by sql-migrate.
+	Baseline bool `db:"baseline"`
 }
 
 var supportedDialects = map[string]DB{}
 
-func getDB(dialect, datasource, tableName string) (DB, error) {
+// DBConfig configures the DBs created by DB.New.
+type DBConfig struct {
+	// Datasource is the data source name, in the format of the driver.
+	Datasource string
+	// TableName is the name of the record table.
+	TableName string
+	// Schema is the schema of the record table, created if missing. If
+	// empty, the record table is created in the default schema of the
+	// session. Only supported by some dialects.
+	Schema string
+	// SearchPath, if set, is the schema search path of the sessions
+	// executing migrations. Only supported by some dialects.
+	SearchPath string
+	// LockTimeout is the maximum time to wait for the migration lock, both
+	// while creating the record table and as the Migrator's LockTimeout.
+	// Zero means wait until the context is done.
+	LockTimeout time.Duration
+}
+
+func getDB(ctx context.Context, dialect string, config *DBConfig) (DB, error) {
 	d, ok := supportedDialects[dialect]
 	if !ok {
-		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, dialect)
 	}
 
-	db, err := d.New(datasource, tableName)
+	db, err := d.New(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to DB: %s", err)
+		return nil, fmt.Errorf("error connecting to DB: %w", err)
+	}
+
+	return db, nil
+}
+
+// createRecordTable creates the record table of db. Creating a table
+// concurrently fails on some databases, so the migration lock is held while
+// doing so if db supports it, waiting at most lockTimeout for it.
+//
+// The lock isn't taken if the records can be read, as the table exists and is
+// up to date then, so that read-only commands don't wait for migrations in
+// progress.
+func createRecordTable(ctx context.Context, db DB, lockTimeout time.Duration) error {
+	if _, err := db.Records(ctx); err == nil {
+		return nil
 	}
 
-	if err := db.CreateRecordTable(); err != nil {
-		return nil, err
+	if locker, ok := db.(Locker); ok {
+		unlock, err := acquireLock(ctx, locker, lockTimeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
 
-	return db, nil
+	return db.CreateRecordTable(ctx)
 }
 
 func RegisterDB(dialect string, db DB) {
@@ -40,21 +98,31 @@ func RegisterDB(dialect string, db DB) {
 type DB interface {
 	SqlExecutor
 
-	New(datasource, tableName string) (DB, error)
-	CreateRecordTable() error
-	Records() ([]*Record, error)
-	Begin() (Tx, error)
+	// New connects to the database described by config. DBs not
+	// supporting a field of config return an error if it is set.
+	New(ctx context.Context, config *DBConfig) (DB, error)
+	CreateRecordTable(ctx context.Context) error
+	Records(ctx context.Context) ([]*Record, error)
+	Begin(ctx context.Context) (Tx, error)
 	Close()
 }
 
 type Tx interface {
 	SqlExecutor
-	Commit() error
-	Rollback() error
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
 }
 
 type SqlExecutor interface {
-	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
-	InsertRecord(record *Record) error
-	DeleteRecord(record *Record) error
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (ExecResult, error)
+	InsertRecord(ctx context.Context, record *Record) error
+	// UpdateRecord overwrites the record with the same ID.
+	UpdateRecord(ctx context.Context, record *Record) error
+	DeleteRecord(ctx context.Context, record *Record) error
+}
+
+// ExecResult is the outcome of a statement executed by a SqlExecutor.
+type ExecResult interface {
+	// RowsAffected returns the number of rows affected by the statement.
+	RowsAffected() int64
 }
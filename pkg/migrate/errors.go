@@ -1,6 +1,35 @@
 package migrate
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The classes of errors returned by the Migrator, which the errors returned
+// match with errors.Is.
+var (
+	// ErrUnknownMigration is returned for migration IDs not among the
+	// migrations found, including the ones of applied migrations.
+	ErrUnknownMigration = errors.New("unknown migration")
+	// ErrNoChange is returned by operations that have nothing to do.
+	ErrNoChange = errors.New("no change")
+	// ErrAlreadyApplied is returned by Baseline when migrations were
+	// applied already.
+	ErrAlreadyApplied = errors.New("migrations were applied already")
+	// ErrLocked is returned when the migration lock couldn't be acquired
+	// within Migrator.LockTimeout.
+	ErrLocked = errors.New("migration lock held")
+	// ErrDirty is returned when planning on top of a migration that failed
+	// halfway without a transaction.
+	ErrDirty = errors.New("dirty migration")
+	// ErrChecksumMismatch is returned when planning on top of a migration
+	// that was modified after it was applied.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnsupportedDialect is returned for dialects no DB was registered
+	// for.
+	ErrUnsupportedDialect = errors.New("unsupported dialect")
+)
 
 // PlanError happens where no migration plan could be created between the sets
 // of already applied migrations and the currently found. For example, when the database
@@ -8,12 +37,15 @@ import "fmt"
 type PlanError struct {
 	Migration *Migration
 	Msg       string
+	// Err is the class of the error, e.g. ErrDirty.
+	Err error
 }
 
-func newPlanError(migration *Migration, msg string) error {
+func newPlanError(migration *Migration, err error, msg string) error {
 	return &PlanError{
 		Migration: migration,
 		Msg:       msg,
+		Err:       err,
 	}
 }
 
@@ -22,21 +54,128 @@ func (p *PlanError) Error() string {
 		p.Migration.ID, p.Msg)
 }
 
+func (p *PlanError) Unwrap() error {
+	return p.Err
+}
+
 // TxError is returned when any error is encountered during a database
-// transaction. It contains the relevant *Migration and notes it's ID in the
-// Error function output.
+// transaction, or by a statement of a migration executed without one. It
+// contains the relevant *Migration and notes it's ID in the Error function
+// output, along with the location of the failed statement, if any.
 type TxError struct {
 	Migration *Migration
 	Err       error
+
+	// Statement is the index of the failed statement among the statements
+	// executed, or -1 if the error didn't come from a statement. Error
+	// numbers statements from 1.
+	Statement int
+	// Line is the line of the migration file the failed statement starts
+	// at, or 0 if unknown.
+	Line int
+	// ErrorLine is the line of the migration file the error happened at, if
+	// the DB reports a position in the failed statement with a
+	// PositionError, or 0. Comment lines removed from the statement by
+	// sqlparse aren't accounted for.
+	ErrorLine int
+	// SQL is an excerpt of the failed statement: the line the error happened
+	// at, if known, or the first line of the statement.
+	SQL string
+}
+
+// PositionError is implemented by the errors of the DBs reporting where in a
+// failed statement the error happened.
+type PositionError interface {
+	error
+	// Position returns the position of the error in the statement, in
+	// characters starting at 1, or 0 if unknown.
+	Position() int
 }
 
+// maxExcerpt is the maximum length of the SQL excerpt of a TxError.
+const maxExcerpt = 80
+
 func newTxError(migration *PlannedMigration, err error) error {
 	return &TxError{
 		Migration: migration.Migration,
 		Err:       err,
+		Statement: -1,
 	}
 }
 
+// newStatementError returns the TxError of the statement of migration at
+// index i, which failed with err.
+func newStatementError(migration *PlannedMigration, i int, err error) error {
+	e := &TxError{
+		Migration: migration.Migration,
+		Err:       err,
+		Statement: i,
+	}
+
+	stmt := migration.Queries[i]
+	start := 0
+	if i < len(migration.Lines) {
+		start = migration.Lines[i]
+	}
+
+	// The statement starts at the first line that isn't blank.
+	lines := strings.Split(stmt, "\n")
+	first := 0
+	for first < len(lines)-1 && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if start > 0 {
+		e.Line = start + first
+	}
+	e.SQL = excerpt(lines[first])
+
+	var perr PositionError
+	if errors.As(err, &perr) && perr.Position() > 0 {
+		// Count the lines before the position, in characters.
+		runes := []rune(stmt)
+		pos := perr.Position() - 1
+		if pos > len(runes) {
+			pos = len(runes)
+		}
+		n := strings.Count(string(runes[:pos]), "\n")
+
+		if start > 0 {
+			e.ErrorLine = start + n
+		}
+		if n < len(lines) {
+			e.SQL = excerpt(lines[n])
+		}
+	}
+
+	return e
+}
+
+// excerpt trims line to at most maxExcerpt characters.
+func excerpt(line string) string {
+	line = strings.TrimSpace(line)
+	if runes := []rune(line); len(runes) > maxExcerpt {
+		return string(runes[:maxExcerpt-3]) + "..."
+	}
+	return line
+}
+
 func (e *TxError) Error() string {
-	return e.Err.Error() + " handling " + e.Migration.ID
+	msg := e.Err.Error() + " handling " + e.Migration.ID
+	if e.Statement < 0 {
+		return msg
+	}
+
+	msg += fmt.Sprintf(", statement %d", e.Statement+1)
+	switch {
+	case e.ErrorLine > 0:
+		msg += fmt.Sprintf(" at line %d", e.ErrorLine)
+	case e.Line > 0:
+		msg += fmt.Sprintf(" starting at line %d", e.Line)
+	}
+
+	return msg + ": " + e.SQL
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type ErrorsSuite struct{}
+
+var _ = Suite(&ErrorsSuite{})
+
+// positionError is a PositionError at a fixed position.
+type positionError int
+
+func (e positionError) Error() string { return "syntax error" }
+func (e positionError) Position() int { return int(e) }
+
+const brokenMigration = `-- +migrate Up
+CREATE TABLE people (id int);
+
+CREATE TABLE pets (
+  id int,
+  FAIL owner int
+);
+`
+
+func (s *ErrorsSuite) TestStatementError(c *C) {
+	mig, err := parse("1_broken.sql", strings.NewReader(brokenMigration))
+	c.Assert(err, IsNil)
+
+	db := newMemDB()
+	migrator := &Migrator{DB: db}
+	_, err = migrator.Exec(&MemorySource{Migrations: []*Migration{mig}}, Up)
+	c.Assert(err, FitsTypeOf, &TxError{})
+
+	txErr := err.(*TxError)
+	c.Assert(txErr.Statement, Equals, 1)
+	c.Assert(txErr.Line, Equals, 4)
+	c.Assert(txErr.ErrorLine, Equals, 0)
+	c.Assert(txErr.SQL, Equals, "CREATE TABLE pets (")
+	c.Assert(err, ErrorMatches, "statement failed handling 1_broken.sql, statement 2 starting at line 4: CREATE TABLE pets \\(")
+}
+
+func (s *ErrorsSuite) TestPositionError(c *C) {
+	mig, err := parse("1_broken.sql", strings.NewReader(brokenMigration))
+	c.Assert(err, IsNil)
+
+	// The position of "FAIL", after the blank line the statement starts
+	// with.
+	pos := strings.Index(mig.Up[1], "FAIL") + 1
+	err = newStatementError(newPlannedMigration(mig, Up), 1, positionError(pos))
+
+	txErr := err.(*TxError)
+	c.Assert(txErr.Line, Equals, 4)
+	c.Assert(txErr.ErrorLine, Equals, 6)
+	c.Assert(txErr.SQL, Equals, "FAIL owner int")
+	c.Assert(err, ErrorMatches, "syntax error handling 1_broken.sql, statement 2 at line 6: FAIL owner int")
+}
+
+func (s *ErrorsSuite) TestUnknownLines(c *C) {
+	mig := &Migration{ID: "1_broken", Up: []string{strings.Repeat("SELECT 1, ", 10) + "FAIL"}}
+
+	err := newStatementError(newPlannedMigration(mig, Up), 0, errors.New("statement failed"))
+
+	txErr := err.(*TxError)
+	c.Assert(txErr.Line, Equals, 0)
+	c.Assert(txErr.SQL, HasLen, maxExcerpt)
+	c.Assert(strings.HasSuffix(txErr.SQL, "..."), Equals, true)
+	c.Assert(err, ErrorMatches, "statement failed handling 1_broken, statement 1: SELECT 1, .*\\.\\.\\.")
+}
+
+func (s *ErrorsSuite) TestNotAStatement(c *C) {
+	err := newTxError(newPlannedMigration(&Migration{ID: "1_broken"}, Up), errors.New("commit failed"))
+
+	c.Assert(err.(*TxError).Statement, Equals, -1)
+	c.Assert(err, ErrorMatches, "commit failed handling 1_broken")
+}
+
+func (s *ErrorsSuite) TestUnwrap(c *C) {
+	err := fmt.Errorf("migration failed: %w",
+		newStatementError(newPlannedMigration(sqlMigrations[0], Up), 0, context.Canceled))
+	c.Assert(errors.Is(err, context.Canceled), Equals, true)
+
+	var txErr *TxError
+	c.Assert(errors.As(err, &txErr), Equals, true)
+	c.Assert(txErr.Migration.ID, Equals, "1_create_table")
+
+	err = fmt.Errorf("error planning migration: %w",
+		newPlanError(sqlMigrations[0], ErrDirty, "migration is dirty"))
+	c.Assert(errors.Is(err, ErrDirty), Equals, true)
+	c.Assert(errors.Is(err, ErrChecksumMismatch), Equals, false)
+}
+
+func (s *ErrorsSuite) TestUnsupportedDialect(c *C) {
+	_, err := New("nope", "", "migrations")
+	c.Assert(errors.Is(err, ErrUnsupportedDialect), Equals, true)
+}
+
+func (s *ErrorsSuite) TestNoChange(c *C) {
+	_, err := Squash("squashed", nil)
+	c.Assert(errors.Is(err, ErrNoChange), Equals, true)
+}
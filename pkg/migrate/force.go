@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ForceApplied marks the migration with the given ID as applied, clearing its
+// dirty state. Use it after completing a dirty migration by hand, or after
+// reapplying one left dirty by a failed Down migration.
+func (m *Migrator) ForceApplied(ctx context.Context, id string) error {
+	return m.force(ctx, id, Up, func(record *Record) error {
+		record.Dirty = false
+		record.Direction = Up
+		return m.DB.UpdateRecord(ctx, record)
+	})
+}
+
+// ForceClean removes the record of the migration with the given ID, marking it
+// as not applied. Use it after undoing a dirty migration by hand.
+func (m *Migrator) ForceClean(ctx context.Context, id string) error {
+	return m.force(ctx, id, Down, func(record *Record) error {
+		return m.DB.DeleteRecord(ctx, record)
+	})
+}
+
+// force applies f to the record of the migration with the given ID, logging
+// it to the history as forcing the migration in dir.
+func (m *Migrator) force(ctx context.Context, id string, dir Direction, f func(record *Record) error) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := m.DB.Records(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.ID == id {
+			start := time.Now()
+			err := f(record)
+			historyErr := m.logHistory(ctx, OperationForce, &PlannedMigration{
+				Migration: &Migration{ID: id},
+				Direction: dir,
+			}, start, err)
+			if err != nil {
+				return err
+			}
+			return historyErr
+		}
+	}
+
+	return fmt.Errorf("no record of migration %s", id)
+}
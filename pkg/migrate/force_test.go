@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type ForceSuite struct {
+	db       *memDB
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&ForceSuite{})
+
+func (s *ForceSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.migrator = &Migrator{DB: s.db}
+	s.source = &MemorySource{Migrations: []*Migration{
+		sqlMigrations[0],
+		{
+			ID:                     "2_concurrent_index",
+			Up:                     []string{"CREATE INDEX CONCURRENTLY people_id ON people (id)", "FAIL"},
+			Down:                   []string{"DROP INDEX people_id", "FAIL"},
+			DisableTransactionUp:   true,
+			DisableTransactionDown: true,
+		},
+		sqlMigrations[2],
+	}}
+}
+
+func (s *ForceSuite) record(c *C, id string) *Record {
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	for _, r := range records {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *ForceSuite) TestNoTransactionSuccess(c *C) {
+	s.source.Migrations[1].Up = s.source.Migrations[1].Up[:1]
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+
+	record := s.record(c, "2_concurrent_index")
+	c.Assert(record, NotNil)
+	c.Assert(record.Dirty, Equals, false)
+}
+
+func (s *ForceSuite) TestFailureLeavesDirty(c *C) {
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+	c.Assert(n, Equals, 1)
+
+	record := s.record(c, "2_concurrent_index")
+	c.Assert(record, NotNil)
+	c.Assert(record.Dirty, Equals, true)
+
+	_, err = s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, FitsTypeOf, &PlanError{})
+	c.Assert(err, ErrorMatches, ".*2_concurrent_index: migration is dirty.*")
+	c.Assert(errors.Is(err, ErrDirty), Equals, true)
+
+	_, err = s.migrator.Exec(s.source, Down)
+	c.Assert(err, FitsTypeOf, &PlanError{})
+}
+
+func (s *ForceSuite) TestForceApplied(c *C) {
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+
+	c.Assert(s.migrator.ForceApplied(context.Background(), "2_concurrent_index"), IsNil)
+	c.Assert(s.record(c, "2_concurrent_index").Dirty, Equals, false)
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1)
+}
+
+func (s *ForceSuite) TestForceClean(c *C) {
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+
+	c.Assert(s.migrator.ForceClean(context.Background(), "2_concurrent_index"), IsNil)
+	c.Assert(s.record(c, "2_concurrent_index"), IsNil)
+
+	plan, err := s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"2_concurrent_index up", "3_add_index up"})
+}
+
+func (s *ForceSuite) TestForceUnknown(c *C) {
+	err := s.migrator.ForceClean(context.Background(), "2_concurrent_index")
+	c.Assert(err, ErrorMatches, "no record of migration 2_concurrent_index")
+}
+
+func (s *ForceSuite) TestDownFailureLeavesDirty(c *C) {
+	s.source.Migrations[1].Up = s.source.Migrations[1].Up[:1]
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+
+	n, err := s.migrator.ExecMax(s.source, Down, 2)
+	c.Assert(err, NotNil)
+	c.Assert(n, Equals, 1)
+
+	record := s.record(c, "2_concurrent_index")
+	c.Assert(record, NotNil)
+	c.Assert(record.Dirty, Equals, true)
+}
+
+func (s *ForceSuite) TestForceAppliedAfterDown(c *C) {
+	s.source.Migrations[1].Up = s.source.Migrations[1].Up[:1]
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+
+	_, err = s.migrator.ExecMax(s.source, Down, 2)
+	c.Assert(err, NotNil)
+	c.Assert(s.record(c, "2_concurrent_index").Direction, Equals, Down)
+
+	c.Assert(s.migrator.ForceApplied(context.Background(), "2_concurrent_index"), IsNil)
+	record := s.record(c, "2_concurrent_index")
+	c.Assert(record.Dirty, Equals, false)
+	c.Assert(record.Direction, Equals, Up)
+
+	plan, err := s.migrator.Plan(s.source, Down, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"2_concurrent_index down", "1_create_table down"})
+}
@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Operation is the kind of operation logged in the migration history.
+type Operation string
+
+const (
+	OperationUp    Operation = "up"
+	OperationDown  Operation = "down"
+	OperationSkip  Operation = "skip"
+	OperationRedo  Operation = "redo"
+	OperationForce Operation = "force"
+	// OperationBaseline marks a migration as applied by Migrator.Baseline.
+	OperationBaseline Operation = "baseline"
+)
+
+// ParseOperation parses the string representation of an Operation.
+func ParseOperation(s string) (Operation, error) {
+	switch op := Operation(s); op {
+	case OperationUp, OperationDown, OperationSkip, OperationRedo, OperationForce, OperationBaseline:
+		return op, nil
+	}
+	return "", fmt.Errorf("unknown operation: %s", s)
+}
+
+// HistoryEntry is an entry of the migration history, logging an operation
+// performed on a single migration and its outcome.
+type HistoryEntry struct {
+	// ID is assigned by the HistoryStore.
+	ID          int64
+	MigrationID string
+	Operation   Operation
+	// Direction is the direction the migration was executed in. For
+	// OperationForce, it is Up when the migration was marked as applied and
+	// Down when it was marked as not applied.
+	Direction Direction
+	StartedAt time.Time
+	Duration  time.Duration
+	Success   bool
+	// Error is the error the operation failed with, if any.
+	Error       string
+	AppliedBy   string
+	Hostname    string
+	ToolVersion string
+}
+
+// HistoryFilter restricts the entries returned by HistoryStore.History. Zero
+// fields don't restrict anything.
+type HistoryFilter struct {
+	MigrationID string
+	Operation   Operation
+	Since       time.Time
+	Until       time.Time
+	FailedOnly  bool
+	// Limit is the maximum number of entries returned, most recent first.
+	Limit int
+}
+
+// HistoryStore is an append-only log of the operations performed on the
+// record table. Unlike records, entries are never deleted, so the history of
+// rolled back migrations is retained.
+//
+// DBs implementing HistoryStore keep the history in a separate table, which
+// is only created and maintained once Migrator.EnableHistory is called.
+type HistoryStore interface {
+	CreateHistoryTable(ctx context.Context) error
+	InsertHistory(ctx context.Context, entry *HistoryEntry) error
+	// History returns the entries matching filter, most recent first.
+	History(ctx context.Context, filter *HistoryFilter) ([]*HistoryEntry, error)
+}
+
+// EnableHistory creates the history table of the DB, if needed, and logs
+// every subsequent operation of the Migrator to it.
+func (m *Migrator) EnableHistory(ctx context.Context) error {
+	history, ok := m.DB.(HistoryStore)
+	if !ok {
+		return fmt.Errorf("history not supported")
+	}
+
+	if err := history.CreateHistoryTable(ctx); err != nil {
+		return err
+	}
+
+	m.History = history
+
+	return nil
+}
+
+// HistoryEntries returns the entries of the migration history matching
+// filter, most recent first.
+func (m *Migrator) HistoryEntries(ctx context.Context, filter *HistoryFilter) ([]*HistoryEntry, error) {
+	if m.History == nil {
+		return nil, fmt.Errorf("history not enabled")
+	}
+
+	return m.History.History(ctx, filter)
+}
+
+// logHistory logs the outcome of op on mig, which started at start and
+// failed with err if not nil. It is a no-op if the history is not enabled.
+func (m *Migrator) logHistory(ctx context.Context, op Operation, mig *PlannedMigration, start time.Time, err error) error {
+	if m.History == nil {
+		return nil
+	}
+
+	// The outcome of an operation aborted by cancelling ctx must be logged
+	// as well.
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+
+	record := m.newRecord(mig, time.Since(start))
+
+	entry := &HistoryEntry{
+		MigrationID: mig.ID,
+		Operation:   op,
+		Direction:   mig.Direction,
+		StartedAt:   start,
+		Duration:    record.Duration,
+		Success:     err == nil,
+		AppliedBy:   record.AppliedBy,
+		Hostname:    record.Hostname,
+		ToolVersion: record.ToolVersion,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if err := m.History.InsertHistory(ctx, entry); err != nil {
+		return fmt.Errorf("error writing history of %s: %w", mig.ID, err)
+	}
+
+	return nil
+}
+
+// operationOf returns the operation of executing a migration in dir.
+func operationOf(dir Direction) Operation {
+	if dir == Down {
+		return OperationDown
+	}
+	return OperationUp
+}
@@ -0,0 +1,132 @@
+package migrate
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+)
+
+// memHistory is an in-memory HistoryStore.
+type memHistory struct {
+	entries []*HistoryEntry
+}
+
+func (h *memHistory) CreateHistoryTable(ctx context.Context) error { return nil }
+
+func (h *memHistory) InsertHistory(ctx context.Context, entry *HistoryEntry) error {
+	entry.ID = int64(len(h.entries) + 1)
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func (h *memHistory) History(ctx context.Context, filter *HistoryFilter) ([]*HistoryEntry, error) {
+	var entries []*HistoryEntry
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		e := h.entries[i]
+		if filter.MigrationID != "" && e.MigrationID != filter.MigrationID {
+			continue
+		}
+		if filter.FailedOnly && e.Success {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func historyLog(entries []*HistoryEntry) []string {
+	log := make([]string, 0, len(entries))
+	for _, e := range entries {
+		outcome := "ok"
+		if !e.Success {
+			outcome = "failed"
+		}
+		log = append(log, e.MigrationID+" "+string(e.Operation)+" "+e.Direction.String()+" "+outcome)
+	}
+	return log
+}
+
+type HistorySuite struct {
+	db       *memDB
+	history  *memHistory
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&HistorySuite{})
+
+func (s *HistorySuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.history = &memHistory{}
+	s.migrator = &Migrator{DB: s.db, History: s.history, ToolVersion: "1.2.3"}
+	s.source = &MemorySource{Migrations: sqlMigrations}
+}
+
+func (s *HistorySuite) TestOperationsAreLogged(c *C) {
+	_, err := s.migrator.SkipMax(s.source, Up, 1)
+	c.Assert(err, IsNil)
+	_, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	_, err = s.migrator.ExecMax(s.source, Down, 1)
+	c.Assert(err, IsNil)
+	_, err = s.migrator.ExecTo(s.source, "3_add_index")
+	c.Assert(err, IsNil)
+
+	redone, err := s.migrator.Redo(s.source)
+	c.Assert(err, IsNil)
+	c.Assert(redone.ID, Equals, "3_add_index")
+
+	c.Assert(s.migrator.ForceClean(context.Background(), "3_add_index"), IsNil)
+	c.Assert(s.db.records, HasLen, 2)
+
+	c.Assert(historyLog(s.history.entries), DeepEquals, []string{
+		"1_create_table skip up ok",
+		"2_alter_table up up ok",
+		"3_add_index up up ok",
+		"3_add_index down down ok",
+		"3_add_index up up ok",
+		"3_add_index redo down ok",
+		"3_add_index redo up ok",
+		"3_add_index force down ok",
+	})
+	c.Assert(s.history.entries[0].ToolVersion, Equals, "1.2.3")
+}
+
+func (s *HistorySuite) TestFailureIsLogged(c *C) {
+	s.source.Migrations = append(s.source.Migrations[:1:1], &Migration{
+		ID: "2_broken",
+		Up: []string{"FAIL"},
+	})
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+
+	entries, err := s.migrator.HistoryEntries(context.Background(), &HistoryFilter{FailedOnly: true})
+	c.Assert(err, IsNil)
+	c.Assert(historyLog(entries), DeepEquals, []string{"2_broken up up failed"})
+	c.Assert(entries[0].Error, Equals, "statement failed handling 2_broken, statement 1: FAIL")
+}
+
+func (s *HistorySuite) TestHistoryNotEnabled(c *C) {
+	s.migrator.History = nil
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+
+	_, err = s.migrator.HistoryEntries(context.Background(), &HistoryFilter{})
+	c.Assert(err, ErrorMatches, "history not enabled")
+
+	err = s.migrator.EnableHistory(context.Background())
+	c.Assert(err, ErrorMatches, "history not supported")
+}
+
+func (s *HistorySuite) TestParseOperation(c *C) {
+	for _, op := range []Operation{OperationUp, OperationDown, OperationSkip, OperationRedo, OperationForce, OperationBaseline} {
+		parsed, err := ParseOperation(string(op))
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, op)
+	}
+
+	_, err := ParseOperation("sideways")
+	c.Assert(err, ErrorMatches, "unknown operation: sideways")
+}
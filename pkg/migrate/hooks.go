@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+)
+
+// Hooks are called by a Migrator around the migrations it executes, when
+// applying or rolling back migrations, migrating to a given migration or
+// redoing one. Skipped and baselined migrations are not executed, so hooks
+// aren't called for them.
+//
+// BeforeEach and AfterEach are called with the transaction of the migration,
+// or the DB if transactions are disabled for it, and an error they return
+// fails the migration. The other hooks are called with the DB.
+type Hooks interface {
+	// BeforeAll is called with the planned migrations before executing
+	// them, unless none are planned. If it fails, none are executed.
+	BeforeAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error
+	// BeforeEach is called before executing the statements of a migration.
+	BeforeEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error
+	// AfterEach is called once a migration was executed and recorded.
+	AfterEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error
+	// AfterAll is called with the executed migrations once all the planned
+	// migrations were executed.
+	AfterAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error
+	// OnError is called with the error a migration failed with, once its
+	// transaction was rolled back.
+	OnError(ctx context.Context, ex SqlExecutor, migration *PlannedMigration, err error)
+}
+
+// NopHooks implements Hooks doing nothing. Embed it to implement some of the
+// hooks only.
+type NopHooks struct{}
+
+var _ Hooks = NopHooks{}
+
+func (NopHooks) BeforeAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return nil
+}
+
+func (NopHooks) BeforeEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error {
+	return nil
+}
+
+func (NopHooks) AfterEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error {
+	return nil
+}
+
+func (NopHooks) AfterAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return nil
+}
+
+func (NopHooks) OnError(ctx context.Context, ex SqlExecutor, migration *PlannedMigration, err error) {
+}
+
+// Tracer delimits the runs of a Migrator executing migrations, and each
+// migration they execute, e.g. to trace them. Unlike Hooks, it returns the
+// context used for the run or the migration, and a function ending it, which
+// is called on every path with the error it failed with, if any.
+type Tracer interface {
+	// StartRun is called with the planned migrations before executing
+	// them, unless none are planned, and before BeforeAll.
+	StartRun(ctx context.Context, migrations []*PlannedMigration) (context.Context, func(err error))
+	// StartMigration is called before executing a migration, and its end
+	// function once its transaction was committed or rolled back.
+	StartMigration(ctx context.Context, migration *PlannedMigration) (context.Context, func(err error))
+}
+
+// SQLHooks runs statements before and after executing migrations: the Up
+// statements of Before and After around applying migrations, and their Down
+// statements around rolling back migrations. When a run rolls back and then
+// reapplies a migration, as redo does, Before runs its Down statements and
+// After its Up statements. The statements are executed outside of a
+// transaction.
+type SQLHooks struct {
+	NopHooks
+
+	Before []*Migration
+	After  []*Migration
+}
+
+var _ Hooks = (*SQLHooks)(nil)
+
+func (h *SQLHooks) BeforeAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return runSQLHooks(ctx, ex, h.Before, migrations[0].Direction)
+}
+
+func (h *SQLHooks) AfterAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return runSQLHooks(ctx, ex, h.After, migrations[len(migrations)-1].Direction)
+}
+
+func runSQLHooks(ctx context.Context, ex SqlExecutor, hooks []*Migration, dir Direction) error {
+	for _, hook := range hooks {
+		stmts := hook.Up
+		if dir == Down {
+			stmts = hook.Down
+		}
+
+		for _, stmt := range stmts {
+			if _, err := ex.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("%s: %w", hook.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
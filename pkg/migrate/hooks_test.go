@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+// recordingHooks logs the hooks called, and fails the hook named fail.
+type recordingHooks struct {
+	log  []string
+	fail string
+}
+
+func (h *recordingHooks) call(name string, ex SqlExecutor) error {
+	kind := "db"
+	if _, ok := ex.(Tx); ok {
+		kind = "tx"
+	}
+	h.log = append(h.log, name+" "+kind)
+
+	if name == h.fail {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (h *recordingHooks) BeforeAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return h.call("BeforeAll", ex)
+}
+
+func (h *recordingHooks) BeforeEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error {
+	return h.call("BeforeEach "+migration.ID, ex)
+}
+
+func (h *recordingHooks) AfterEach(ctx context.Context, ex SqlExecutor, migration *PlannedMigration) error {
+	return h.call("AfterEach "+migration.ID, ex)
+}
+
+func (h *recordingHooks) AfterAll(ctx context.Context, ex SqlExecutor, migrations []*PlannedMigration) error {
+	return h.call("AfterAll", ex)
+}
+
+func (h *recordingHooks) OnError(ctx context.Context, ex SqlExecutor, migration *PlannedMigration, err error) {
+	_ = h.call("OnError "+migration.ID, ex)
+}
+
+type HooksSuite struct {
+	db       *memDB
+	hooks    *recordingHooks
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&HooksSuite{})
+
+func (s *HooksSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.hooks = &recordingHooks{}
+	s.migrator = &Migrator{DB: s.db, Hooks: s.hooks}
+	s.source = &MemorySource{Migrations: sqlMigrations[:2]}
+}
+
+func (s *HooksSuite) TestHooks(c *C) {
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.hooks.log, DeepEquals, []string{
+		"BeforeAll db",
+		"BeforeEach 1_create_table tx",
+		"AfterEach 1_create_table tx",
+		"BeforeEach 2_alter_table tx",
+		"AfterEach 2_alter_table tx",
+		"AfterAll db",
+	})
+
+	// Nothing to do.
+	s.hooks.log = nil
+	_, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(s.hooks.log, HasLen, 0)
+}
+
+func (s *HooksSuite) TestBeforeAllFails(c *C) {
+	s.hooks.fail = "BeforeAll"
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, ErrorMatches, "error running before hook: BeforeAll failed")
+	c.Assert(n, Equals, 0)
+	c.Assert(s.db.log, HasLen, 0)
+}
+
+func (s *HooksSuite) TestAfterEachFails(c *C) {
+	s.hooks.fail = "AfterEach 2_alter_table"
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, FitsTypeOf, &TxError{})
+	c.Assert(err, ErrorMatches, ".*error running after hook: AfterEach 2_alter_table failed.*")
+	c.Assert(n, Equals, 1)
+	c.Assert(s.hooks.log[len(s.hooks.log)-1], Equals, "OnError 2_alter_table db")
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+}
+
+func (s *HooksSuite) TestSQLHooks(c *C) {
+	analyze := &Migration{
+		ID:   "analyze.sql",
+		Up:   []string{"ANALYZE"},
+		Down: []string{"VACUUM"},
+	}
+	s.migrator.Hooks = &SQLHooks{
+		Before: []*Migration{{ID: "timeout.sql", Up: []string{"SET lock_timeout = 1000"}}},
+		After:  []*Migration{analyze},
+	}
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(s.db.log, DeepEquals, []string{
+		"SET lock_timeout = 1000",
+		sqlMigrations[0].Up[0],
+		sqlMigrations[1].Up[0],
+		"ANALYZE",
+	})
+
+	s.db.log = nil
+	_, err = s.migrator.ExecMax(s.source, Down, 1)
+	c.Assert(err, IsNil)
+	c.Assert(s.db.log, DeepEquals, []string{sqlMigrations[1].Down[0], "VACUUM"})
+
+	analyze.Down = []string{"FAIL"}
+	_, err = s.migrator.ExecMax(s.source, Down, 1)
+	c.Assert(err, ErrorMatches, "error running after hook: analyze.sql: .*")
+}
+
+func (s *HooksSuite) TestRedoRunsHooksOnce(c *C) {
+	s.migrator.Hooks = &SQLHooks{
+		Before: []*Migration{{ID: "before.sql", Up: []string{"BEFORE UP"}, Down: []string{"BEFORE DOWN"}}},
+		After:  []*Migration{{ID: "after.sql", Up: []string{"AFTER UP"}, Down: []string{"AFTER DOWN"}}},
+	}
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+
+	s.db.log = nil
+	_, err = s.migrator.Redo(s.source)
+	c.Assert(err, IsNil)
+	c.Assert(s.db.log, DeepEquals, []string{
+		"BEFORE DOWN",
+		sqlMigrations[1].Down[0],
+		sqlMigrations[1].Up[0],
+		"AFTER UP",
+	})
+}
@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Locker serializes migrations run concurrently against the same record
+// table, for example by several replicas of a service that all migrate the
+// database at startup.
+//
+// DBs implementing Locker are used as the Migrator's Locker by New. Another
+// implementation can be plugged in by setting Migrator.Locker.
+type Locker interface {
+	// Lock blocks until the lock is acquired or ctx is done.
+	Lock(ctx context.Context) error
+	// Unlock releases the lock. The lock must be released even if an error
+	// is returned.
+	Unlock(ctx context.Context) error
+	// LockStatus reports whether the lock is currently held by anyone.
+	LockStatus(ctx context.Context) (*LockStatus, error)
+	// ForceUnlock releases the lock regardless of who holds it.
+	ForceUnlock(ctx context.Context) error
+}
+
+// LockStatus describes the state of a Locker.
+type LockStatus struct {
+	Locked bool
+	// Holder is a human readable description of who holds the lock, if
+	// known.
+	Holder string
+}
+
+// LockStatus reports the state of the migration lock.
+func (m *Migrator) LockStatus(ctx context.Context) (*LockStatus, error) {
+	if m.Locker == nil {
+		return nil, fmt.Errorf("locking not supported")
+	}
+
+	return m.Locker.LockStatus(ctx)
+}
+
+// ForceUnlock releases the migration lock, even if it is held by another
+// process. It is meant to recover from a process that died while holding the
+// lock without the lock being released.
+func (m *Migrator) ForceUnlock(ctx context.Context) error {
+	if m.Locker == nil {
+		return fmt.Errorf("locking not supported")
+	}
+
+	return m.Locker.ForceUnlock(ctx)
+}
+
+// lock acquires the migration lock, waiting at most m.LockTimeout. The
+// returned function releases it. Without a Locker, lock is a no-op.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if m.Locker == nil {
+		return func() {}, nil
+	}
+
+	return acquireLock(ctx, m.Locker, m.LockTimeout)
+}
+
+// acquireLock acquires locker, waiting at most timeout, or until ctx is done
+// if timeout is zero. The returned function releases it.
+func acquireLock(ctx context.Context, locker Locker, timeout time.Duration) (func(), error) {
+	lockCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := locker.Lock(lockCtx); err != nil {
+		if ctx.Err() == nil && lockCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s waiting for migration lock: %w", timeout, ErrLocked)
+		}
+		return nil, fmt.Errorf("error acquiring migration lock: %w", err)
+	}
+
+	return func() {
+		// As with rollback, ctx may already be cancelled here.
+		_ = locker.Unlock(context.Background())
+	}, nil
+}
@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// chanLocker is a Locker backed by a buffered channel of size one.
+type chanLocker struct {
+	ch      chan struct{}
+	unlocks int
+}
+
+func newChanLocker() *chanLocker {
+	return &chanLocker{ch: make(chan struct{}, 1)}
+}
+
+func (l *chanLocker) Lock(ctx context.Context) error {
+	select {
+	case l.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *chanLocker) Unlock(ctx context.Context) error {
+	<-l.ch
+	l.unlocks++
+	return nil
+}
+
+func (l *chanLocker) LockStatus(ctx context.Context) (*LockStatus, error) {
+	return &LockStatus{Locked: len(l.ch) > 0}, nil
+}
+
+func (l *chanLocker) ForceUnlock(ctx context.Context) error {
+	select {
+	case <-l.ch:
+	default:
+	}
+	return nil
+}
+
+type LockSuite struct {
+	locker   *chanLocker
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&LockSuite{})
+
+func (s *LockSuite) SetUpTest(c *C) {
+	s.locker = newChanLocker()
+	s.migrator = &Migrator{DB: newMemDB(), Locker: s.locker}
+	s.source = &MemorySource{Migrations: sqlMigrations}
+}
+
+func (s *LockSuite) TestExecHoldsLock(c *C) {
+	s.migrator.DB.(*memDB).beforeExec = func(string) {
+		status, err := s.migrator.LockStatus(context.Background())
+		c.Assert(err, IsNil)
+		c.Assert(status.Locked, Equals, true)
+	}
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+	c.Assert(s.locker.unlocks, Equals, 1)
+
+	status, err := s.migrator.LockStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(status.Locked, Equals, false)
+}
+
+func (s *LockSuite) TestUnlockOnError(c *C) {
+	s.source.Migrations = []*Migration{{ID: "1_broken", Up: []string{"FAIL"}}}
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+	c.Assert(s.locker.unlocks, Equals, 1)
+}
+
+func (s *LockSuite) TestLockTimeout(c *C) {
+	c.Assert(s.locker.Lock(context.Background()), IsNil)
+	s.migrator.LockTimeout = 10 * time.Millisecond
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, ErrorMatches, "timed out after 10ms waiting for migration lock: migration lock held")
+	c.Assert(errors.Is(err, ErrLocked), Equals, true)
+	c.Assert(n, Equals, 0)
+
+	_, err = s.migrator.SkipMax(s.source, Up, 0)
+	c.Assert(err, ErrorMatches, "timed out .*")
+
+	c.Assert(s.migrator.ForceUnlock(context.Background()), IsNil)
+
+	n, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+}
+
+func (s *LockSuite) TestNoLocker(c *C) {
+	s.migrator.Locker = nil
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+
+	_, err = s.migrator.LockStatus(context.Background())
+	c.Assert(err, ErrorMatches, "locking not supported")
+}
+
+// lockerDB is a memDB that is also a Locker. New returns the lockerDB
+// itself, so that tests can hold its lock beforehand.
+type lockerDB struct {
+	*memDB
+	*chanLocker
+}
+
+func (db *lockerDB) New(ctx context.Context, config *DBConfig) (DB, error) {
+	return db, nil
+}
+
+func (s *LockSuite) TestNewLockTimeout(c *C) {
+	db := &lockerDB{memDB: newMemDB(), chanLocker: s.locker}
+	db.noRecordTable = true
+	RegisterDB("lockerdb", db)
+	c.Assert(s.locker.Lock(context.Background()), IsNil)
+
+	config := &DBConfig{TableName: "migrations", LockTimeout: 10 * time.Millisecond}
+	_, err := NewFromConfig(context.Background(), "lockerdb", config)
+	c.Assert(err, ErrorMatches, "timed out after 10ms waiting for migration lock: migration lock held")
+	c.Assert(errors.Is(err, ErrLocked), Equals, true)
+
+	// Connect does not wait for the lock, so that it can be released.
+	migrator, err := Connect(context.Background(), "lockerdb", config)
+	c.Assert(err, IsNil)
+	status, err := migrator.LockStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(status.Locked, Equals, true)
+	c.Assert(migrator.ForceUnlock(context.Background()), IsNil)
+
+	migrator, err = NewFromConfig(context.Background(), "lockerdb", config)
+	c.Assert(err, IsNil)
+	c.Assert(migrator.LockTimeout, Equals, 10*time.Millisecond)
+	c.Assert(s.locker.unlocks, Equals, 1)
+}
+
+func (s *LockSuite) TestNewExistingRecordTable(c *C) {
+	db := &lockerDB{memDB: newMemDB(), chanLocker: s.locker}
+	RegisterDB("lockerdb", db)
+	c.Assert(s.locker.Lock(context.Background()), IsNil)
+
+	// The lock is only needed to create the record table, so read-only
+	// commands don't wait for it once it exists.
+	config := &DBConfig{TableName: "migrations", LockTimeout: 10 * time.Millisecond}
+	migrator, err := NewFromConfig(context.Background(), "lockerdb", config)
+	c.Assert(err, IsNil)
+
+	records, err := migrator.DB.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
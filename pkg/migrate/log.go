@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"context"
+)
+
+// Logger receives the events of a Migrator: the computed plans, the
+// migrations started and finished, the statements executed and the failed
+// migrations. Events are reported as a message followed by alternating keys
+// and values, as with log/slog, and *slog.Logger implements Logger.
+//
+// Statements are reported at the debug level, failures at the error level
+// and other events at the info level.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// nopLogger discards all events.
+type nopLogger struct{}
+
+func (nopLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {}
+func (nopLogger) InfoContext(ctx context.Context, msg string, args ...interface{})  {}
+func (nopLogger) WarnContext(ctx context.Context, msg string, args ...interface{})  {}
+func (nopLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {}
+
+type loggerKey struct{}
+
+// LoggerFromContext returns the Logger of the Migrator executing migrations
+// with ctx, for DBs to report events through. It returns a Logger discarding
+// all events if ctx is not the context of a Migrator.
+func LoggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return logger
+	}
+	return nopLogger{}
+}
+
+// logger returns the Logger of m, or a Logger discarding all events.
+func (m *Migrator) logger() Logger {
+	if m.Logger == nil {
+		return nopLogger{}
+	}
+	return m.Logger
+}
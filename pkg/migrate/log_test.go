@@ -0,0 +1,91 @@
+//go:build go1.21
+// +build go1.21
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	. "gopkg.in/check.v1"
+)
+
+type LogSuite struct {
+	db       *memDB
+	buf      bytes.Buffer
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&LogSuite{})
+
+var _ Logger = (*slog.Logger)(nil)
+
+func (s *LogSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.buf.Reset()
+	s.migrator = &Migrator{
+		DB:     s.db,
+		Logger: slog.New(slog.NewJSONHandler(&s.buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	s.source = &MemorySource{Migrations: sqlMigrations[:1]}
+}
+
+// events returns the logged events, as message and migration.
+func (s *LogSuite) events(c *C) []string {
+	var events []string
+	for _, line := range bytes.Split(bytes.TrimSpace(s.buf.Bytes()), []byte("\n")) {
+		var event struct {
+			Msg       string
+			Migration string
+		}
+		c.Assert(json.Unmarshal(line, &event), IsNil)
+		events = append(events, event.Msg+" "+event.Migration)
+	}
+	return events
+}
+
+func (s *LogSuite) TestEvents(c *C) {
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(s.events(c), DeepEquals, []string{
+		"plan computed ",
+		"migration started 1_create_table",
+		"statement executed 1_create_table",
+		"migration finished 1_create_table",
+	})
+}
+
+func (s *LogSuite) TestLoggerFromContext(c *C) {
+	var goSrc GoSource
+	goSrc.Register("1_warn", func(ctx context.Context, ex SqlExecutor) error {
+		LoggerFromContext(ctx).WarnContext(ctx, "statement warning")
+		return nil
+	}, nil)
+
+	_, err := s.migrator.Exec(&goSrc, Up)
+	c.Assert(err, IsNil)
+	c.Assert(s.events(c), DeepEquals, []string{
+		"plan computed ",
+		"migration started 1_warn",
+		"statement warning ",
+		"migration finished 1_warn",
+	})
+
+	LoggerFromContext(context.Background()).WarnContext(context.Background(), "discarded")
+}
+
+func (s *LogSuite) TestRollback(c *C) {
+	s.source.Migrations = []*Migration{{ID: "1_broken", Up: []string{"FAIL"}}}
+
+	_, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, NotNil)
+	c.Assert(s.events(c), DeepEquals, []string{
+		"plan computed ",
+		"migration started 1_broken",
+		"migration rolled back 1_broken",
+	})
+	c.Assert(s.buf.String(), Matches, `(?s).*"level":"ERROR","msg":"migration rolled back".*"error":"statement failed handling 1_broken, statement 1: FAIL".*`)
+}
@@ -0,0 +1,234 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memDB is an in-memory DB used to exercise the Migrator without a database
+// server. Statements are not interpreted, they are only logged; a statement
+// containing "FAIL" returns an error.
+type memDB struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	log     []string
+
+	// beforeExec, if set, is called before every statement is executed.
+	beforeExec func(stmt string)
+	// noRecordTable is set while the record table isn't created, which fails
+	// Records.
+	noRecordTable bool
+}
+
+var _ DB = (*memDB)(nil)
+
+func newMemDB() *memDB {
+	return &memDB{
+		records: make(map[string]*Record),
+	}
+}
+
+func (db *memDB) New(ctx context.Context, config *DBConfig) (DB, error) {
+	return newMemDB(), nil
+}
+
+func (db *memDB) CreateRecordTable(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.noRecordTable = false
+	return nil
+}
+
+func (db *memDB) Records(ctx context.Context) ([]*Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.noRecordTable {
+		return nil, errors.New("no record table")
+	}
+
+	records := make([]*Record, 0, len(db.records))
+	for _, r := range db.records {
+		record := *r
+		records = append(records, &record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	return records, nil
+}
+
+func (db *memDB) Begin(ctx context.Context) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &memTx{db: db}, nil
+}
+
+func (db *memDB) Close() {}
+
+func (db *memDB) Exec(ctx context.Context, sql string, arguments ...interface{}) (ExecResult, error) {
+	if db.beforeExec != nil {
+		db.beforeExec(sql)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.Contains(sql, "FAIL") {
+		return nil, errors.New("statement failed")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.log = append(db.log, sql)
+
+	return memResult(1), nil
+}
+
+func (db *memDB) InsertRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.records[record.ID]; ok {
+		return errors.New("duplicate key " + record.ID)
+	}
+	r := *record
+	db.records[record.ID] = &r
+
+	return nil
+}
+
+func (db *memDB) UpdateRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.records[record.ID]; !ok {
+		return errors.New("no record " + record.ID)
+	}
+	r := *record
+	db.records[record.ID] = &r
+
+	return nil
+}
+
+func (db *memDB) DeleteRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.records, record.ID)
+
+	return nil
+}
+
+// memResult is the ExecResult of a statement executed on a memDB.
+type memResult int64
+
+func (r memResult) RowsAffected() int64 {
+	return int64(r)
+}
+
+// memTx buffers operations and applies them to its memDB on Commit.
+type memTx struct {
+	db  *memDB
+	ops []func(ctx context.Context) error
+}
+
+var _ Tx = (*memTx)(nil)
+
+func (tx *memTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (ExecResult, error) {
+	if tx.db.beforeExec != nil {
+		tx.db.beforeExec(sql)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.Contains(sql, "FAIL") {
+		return nil, errors.New("statement failed")
+	}
+
+	tx.ops = append(tx.ops, func(ctx context.Context) error {
+		_, err := tx.db.Exec(ctx, sql, arguments...)
+		return err
+	})
+
+	return memResult(1), nil
+}
+
+func (tx *memTx) InsertRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.ops = append(tx.ops, func(ctx context.Context) error {
+		return tx.db.InsertRecord(ctx, record)
+	})
+
+	return nil
+}
+
+func (tx *memTx) UpdateRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.ops = append(tx.ops, func(ctx context.Context) error {
+		return tx.db.UpdateRecord(ctx, record)
+	})
+
+	return nil
+}
+
+func (tx *memTx) DeleteRecord(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.ops = append(tx.ops, func(ctx context.Context) error {
+		return tx.db.DeleteRecord(ctx, record)
+	})
+
+	return nil
+}
+
+func (tx *memTx) Commit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, op := range tx.ops {
+		if err := op(ctx); err != nil {
+			return err
+		}
+	}
+	tx.ops = nil
+
+	return nil
+}
+
+func (tx *memTx) Rollback(ctx context.Context) error {
+	tx.ops = nil
+	return nil
+}
@@ -2,8 +2,11 @@ package migrate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"os/user"
 	"sort"
 	"time"
 
@@ -17,18 +20,120 @@ const (
 	Down
 )
 
+func (d Direction) String() string {
+	switch d {
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// ParseDirection parses the string representation of a Direction, as
+// returned by Direction.String.
+func ParseDirection(s string) (Direction, error) {
+	switch s {
+	case "up":
+		return Up, nil
+	case "down":
+		return Down, nil
+	}
+	return 0, fmt.Errorf("unknown direction: %s", s)
+}
+
 type Migrator struct {
 	DB
+
+	// Locker, if set, is held while migrations are planned and executed.
+	Locker Locker
+	// LockTimeout is the maximum time to wait for Locker. Zero means wait
+	// until the context is done.
+	LockTimeout time.Duration
+
+	// History, if set, logs every operation performed. See EnableHistory.
+	History HistoryStore
+
+	// Hooks, if set, are called around the executed migrations.
+	Hooks Hooks
+
+	// Tracer, if set, delimits the runs and the executed migrations.
+	Tracer Tracer
+
+	// Logger, if set, receives the events of the Migrator.
+	Logger Logger
+
+	// ToolVersion is stored in the records of the executed migrations, to
+	// identify the version of the program that executed them.
+	ToolVersion string
 }
 
 func New(dialect, datasource, tableName string) (*Migrator, error) {
-	db, err := getDB(dialect, datasource, tableName)
+	return NewContext(context.Background(), dialect, datasource, tableName)
+}
+
+// NewContext is like New, but uses ctx to connect to the database and create
+// the record table.
+func NewContext(ctx context.Context, dialect, datasource, tableName string) (*Migrator, error) {
+	return NewFromConfig(ctx, dialect, &DBConfig{
+		Datasource: datasource,
+		TableName:  tableName,
+	})
+}
+
+// NewFromConfig is like NewContext, but takes the full configuration of the
+// DB.
+func NewFromConfig(ctx context.Context, dialect string, config *DBConfig) (*Migrator, error) {
+	db, err := getDB(ctx, dialect, config)
 	if err != nil {
 		return nil, err
 	}
 
+	m, err := newFromDB(ctx, db, config.LockTimeout)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Connect is like NewFromConfig, but neither creates the record table nor
+// takes the migration lock. The returned Migrator is only meant to inspect
+// or release the migration lock, see LockStatus and ForceUnlock.
+func Connect(ctx context.Context, dialect string, config *DBConfig) (*Migrator, error) {
+	db, err := getDB(ctx, dialect, config)
+	if err != nil {
+		return nil, err
+	}
+
+	locker, _ := db.(Locker)
+
 	return &Migrator{
-		DB: db,
+		DB:          db,
+		Locker:      locker,
+		LockTimeout: config.LockTimeout,
+	}, nil
+}
+
+// NewFromDB returns a Migrator for db, a DB that is already connected, and
+// creates its record table. Unlike the DBs created by New, db is not
+// registered as a dialect. Closing the Migrator closes db.
+func NewFromDB(ctx context.Context, db DB) (*Migrator, error) {
+	return newFromDB(ctx, db, 0)
+}
+
+func newFromDB(ctx context.Context, db DB, lockTimeout time.Duration) (*Migrator, error) {
+	if err := createRecordTable(ctx, db, lockTimeout); err != nil {
+		return nil, err
+	}
+
+	locker, _ := db.(Locker)
+
+	return &Migrator{
+		DB:          db,
+		Locker:      locker,
+		LockTimeout: lockTimeout,
 	}, nil
 }
 
@@ -40,21 +145,31 @@ func parse(id string, r io.ReadSeeker) (*Migration, error) {
 
 	parsed, err := sqlparse.ParseMigration(r)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing migration (%s): %s", id, err)
+		return nil, fmt.Errorf("error parsing migration (%s): %w", id, err)
 	}
 
 	m.Up = parsed.UpStatements
 	m.Down = parsed.DownStatements
+	m.UpLines = parsed.UpLines
+	m.DownLines = parsed.DownLines
 
 	m.DisableTransactionUp = parsed.DisableTransactionUp
 	m.DisableTransactionDown = parsed.DisableTransactionDown
 
+	m.Repeatable = parsed.Repeatable
+	m.Squashes = parsed.Squashes
+
 	return m, nil
 }
 
 // Exec executes a set of migrations and returns the number of applied migrations.
 func (m *Migrator) Exec(src Source, dir Direction) (int, error) {
-	return m.ExecMax(src, dir, 0)
+	return m.ExecMaxContext(context.Background(), src, dir, 0)
+}
+
+// ExecContext is like Exec, but uses ctx for all database operations.
+func (m *Migrator) ExecContext(ctx context.Context, src Source, dir Direction) (int, error) {
+	return m.ExecMaxContext(ctx, src, dir, 0)
 }
 
 // ExecMax executes a set of migrations, up to a maximum of max migrations, and
@@ -62,111 +177,248 @@ func (m *Migrator) Exec(src Source, dir Direction) (int, error) {
 //
 // Pass 0 for no limit (or use Exec).
 func (m *Migrator) ExecMax(src Source, dir Direction, max int) (int, error) {
-	// TODO: run migration + record insert in transaction
+	return m.ExecMaxContext(context.Background(), src, dir, max)
+}
+
+// ExecMaxContext is like ExecMax, but uses ctx for all database operations.
+//
+// If ctx is cancelled or its deadline expires while a migration is running,
+// the transaction of that migration is rolled back and the error is returned.
+// Migrations applied before that remain applied.
+func (m *Migrator) ExecMaxContext(ctx context.Context, src Source, dir Direction, max int) (int, error) {
+	migrations, err := m.ExecMaxMigrationsContext(ctx, src, dir, max)
+	return len(migrations), err
+}
 
-	migrations, err := m.Plan(src, dir, max)
+// ExecMaxMigrationsContext is like ExecMaxContext, but returns the applied
+// migrations instead of their number. They are planned while holding the
+// migration lock, so they are exactly the migrations that were executed.
+func (m *Migrator) ExecMaxMigrationsContext(ctx context.Context, src Source, dir Direction, max int) ([]*PlannedMigration, error) {
+	unlock, err := m.lock(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	defer unlock()
+
+	migrations, err := m.PlanContext(ctx, src, dir, max)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.execute(ctx, migrations, operationOf(dir))
+}
+
+// execute applies the planned migrations in order and returns the applied
+// migrations. Each migration is logged to the history as op.
+func (m *Migrator) execute(ctx context.Context, migrations []*PlannedMigration, op Operation) (_ []*PlannedMigration, err error) {
+	ctx = context.WithValue(ctx, loggerKey{}, m.logger())
+
+	if m.Tracer != nil && len(migrations) > 0 {
+		var end func(error)
+		ctx, end = m.Tracer.StartRun(ctx, migrations)
+		defer func() { end(err) }()
+	}
+
+	if m.Hooks != nil && len(migrations) > 0 {
+		if err := m.Hooks.BeforeAll(ctx, m.DB, migrations); err != nil {
+			return nil, fmt.Errorf("error running before hook: %w", err)
+		}
 	}
 
 	// Apply migrations
 	applied := 0
 	for _, mig := range migrations {
-		var executor SqlExecutor
+		start := time.Now()
+		err := m.traceMigration(ctx, mig)
+		historyErr := m.logHistory(ctx, op, mig, start, err)
+		if err != nil {
+			m.onError(ctx, mig, err)
+			return migrations[:applied], err
+		}
+
+		applied++
+
+		if historyErr != nil {
+			return migrations[:applied], historyErr
+		}
+	}
+
+	if m.Hooks != nil && len(migrations) > 0 {
+		if err := m.Hooks.AfterAll(ctx, m.DB, migrations); err != nil {
+			return migrations, fmt.Errorf("error running after hook: %w", err)
+		}
+	}
+
+	return migrations, nil
+}
 
+// onError calls the OnError hook, if any, with the error mig failed with.
+func (m *Migrator) onError(ctx context.Context, mig *PlannedMigration, err error) {
+	if m.Hooks == nil {
+		return
+	}
+
+	// The hook must be called when ctx was cancelled as well.
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+
+	m.Hooks.OnError(ctx, m.DB, mig, err)
+}
+
+// traceMigration executes a single migration, delimited by the Tracer of m,
+// if any.
+func (m *Migrator) traceMigration(ctx context.Context, mig *PlannedMigration) error {
+	if m.Tracer == nil {
+		return m.executeMigration(ctx, mig)
+	}
+
+	ctx, end := m.Tracer.StartMigration(ctx, mig)
+	err := m.executeMigration(ctx, mig)
+	end(err)
+	return err
+}
+
+// executeMigration applies a single planned migration.
+func (m *Migrator) executeMigration(ctx context.Context, mig *PlannedMigration) error {
+	var (
+		executor SqlExecutor
+		records  []*Record
+		err      error
+	)
+
+	m.logger().InfoContext(ctx, "migration started",
+		"migration", mig.ID, "direction", mig.Direction.String(), "transaction", !mig.DisableTransaction)
+	started := time.Now()
+
+	// Rolling back a squashed migration needs the records of the migrations
+	// it squashed. They are read before beginning the transaction, as some
+	// DBs only have a single connection.
+	if mig.Direction == Down && len(mig.Squashes) > 0 {
+		records, err = m.DB.Records(ctx)
+		if err != nil {
+			return newTxError(mig, err)
+		}
+	}
+
+	if mig.DisableTransaction {
+		executor = m.DB
+	} else {
+		executor, err = m.DB.Begin(ctx)
+		if err != nil {
+			return newTxError(mig, err)
+		}
+	}
+
+	err = func() error {
+		if records != nil {
+			if err := adoptSquashed(ctx, executor, mig, records); err != nil {
+				return err
+			}
+		}
+
+		// Without a transaction, a failure leaves the migration
+		// partially applied. Mark it as dirty beforehand, so that this
+		// doesn't go unnoticed.
 		if mig.DisableTransaction {
-			executor = m.DB
-		} else {
-			executor, err = m.DB.Begin()
-			if err != nil {
-				return applied, newTxError(mig, err)
+			if err := markDirty(ctx, executor, m.newRecord(mig, 0), mig.recorded); err != nil {
+				return err
 			}
 		}
 
-		err := func() error {
-			for _, stmt := range mig.Queries {
-				if _, err := executor.Exec(context.Background(), stmt); err != nil {
-					return err
-				}
+		if m.Hooks != nil {
+			if err := m.Hooks.BeforeEach(ctx, executor, mig); err != nil {
+				return fmt.Errorf("error running before hook: %w", err)
 			}
+		}
 
-			switch dir {
-			case Up:
-				err = executor.InsertRecord(&Record{
-					ID:        mig.ID,
-					AppliedAt: time.Now(),
-				})
-				if err != nil {
-					return err
-				}
-			case Down:
-				err := executor.DeleteRecord(&Record{
-					ID: mig.ID,
-				})
-				if err != nil {
-					return err
-				}
-			default:
-				panic(fmt.Sprintf("unexpected direction: %v", dir))
+		start := time.Now()
+
+		for i, stmt := range mig.Queries {
+			stmtStart := time.Now()
+			if _, err := executor.Exec(ctx, stmt); err != nil {
+				return newStatementError(mig, i, err)
 			}
+			m.logger().DebugContext(ctx, "statement executed",
+				"migration", mig.ID, "statement", stmt, "duration", time.Since(stmtStart))
+		}
 
-			return nil
-		}()
+		if mig.Func != nil {
+			if err := mig.Func(ctx, executor); err != nil {
+				return err
+			}
+		}
 
-		if tx, ok := executor.(Tx); ok {
-			if err != nil {
-				tx.Rollback()
-				return applied, newTxError(mig, err)
+		switch mig.Direction {
+		case Up:
+			record := m.newRecord(mig, time.Since(start))
+			if mig.DisableTransaction || mig.recorded {
+				err = executor.UpdateRecord(ctx, record)
+			} else {
+				err = executor.InsertRecord(ctx, record)
 			}
-			if err := tx.Commit(); err != nil {
-				tx.Rollback()
-				return applied, newTxError(mig, err)
+			if err != nil {
+				return err
 			}
-		} else {
+		case Down:
+			err := executor.DeleteRecord(ctx, &Record{
+				ID: mig.ID,
+			})
 			if err != nil {
-				return applied, err
+				return err
 			}
+		default:
+			panic(fmt.Sprintf("unexpected direction: %v", mig.Direction))
 		}
 
-		applied++
+		if m.Hooks != nil {
+			if err := m.Hooks.AfterEach(ctx, executor, mig); err != nil {
+				return fmt.Errorf("error running after hook: %w", err)
+			}
+		}
+
+		return nil
+	}()
+
+	if tx, ok := executor.(Tx); ok {
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			rollback(tx)
+			m.logger().ErrorContext(ctx, "migration rolled back",
+				"migration", mig.ID, "direction", mig.Direction.String(), "error", err)
+			var txErr *TxError
+			if errors.As(err, &txErr) {
+				return err
+			}
+			return newTxError(mig, err)
+		}
+	} else if err != nil {
+		m.logger().ErrorContext(ctx, "migration failed without a transaction",
+			"migration", mig.ID, "direction", mig.Direction.String(), "error", err)
+		return err
 	}
 
-	return applied, nil
+	m.logger().InfoContext(ctx, "migration finished",
+		"migration", mig.ID, "direction", mig.Direction.String(), "duration", time.Since(started))
+
+	return nil
 }
 
 // Plan a migration.
 func (m *Migrator) Plan(src Source, dir Direction, max int) ([]*PlannedMigration, error) {
-	migrations, err := src.Find()
-	if err != nil {
-		return nil, err
-	}
+	return m.PlanContext(context.Background(), src, dir, max)
+}
 
-	records, err := m.DB.Records()
+// PlanContext is like Plan, but uses ctx to read the applied migrations from
+// the database.
+func (m *Migrator) PlanContext(ctx context.Context, src Source, dir Direction, max int) ([]*PlannedMigration, error) {
+	migrations, existingMigrations, repeatables, err := m.load(ctx, src)
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort migrations that have been run by ID.
-	var existingMigrations []*Migration
-	for _, migrationRecord := range records {
-		existingMigrations = append(existingMigrations, &Migration{
-			ID: migrationRecord.ID,
-		})
-	}
-	sort.Sort(byID(existingMigrations))
-
-	// Make sure all migrations in the database are among the found migrations which
-	// are to be applied.
-	migrationsSearch := make(map[string]struct{})
-	for _, migration := range migrations {
-		migrationsSearch[migration.ID] = struct{}{}
-	}
-	for _, existingMigration := range existingMigrations {
-		if _, ok := migrationsSearch[existingMigration.ID]; !ok {
-			return nil, newPlanError(existingMigration, "unknown migration in database")
-		}
-	}
-
 	// Get last migration that was run
 	record := &Migration{}
 	if len(existingMigrations) > 0 {
@@ -188,78 +440,393 @@ func (m *Migrator) Plan(src Source, dir Direction, max int) ([]*PlannedMigration
 		toApplyCount = max
 	}
 	for _, v := range toApply[0:toApplyCount] {
-		if dir == Up {
-			result = append(result, &PlannedMigration{
-				Migration:          v,
-				Queries:            v.Up,
-				DisableTransaction: v.DisableTransactionUp,
-			})
-		} else if dir == Down {
-			result = append(result, &PlannedMigration{
-				Migration:          v,
-				Queries:            v.Down,
-				DisableTransaction: v.DisableTransactionDown,
-			})
+		result = append(result, newPlannedMigration(v, dir))
+	}
+
+	// Repeatable migrations come after all other migrations, within the
+	// limit.
+	if dir == Up && toApplyCount == len(toApply) {
+		for _, v := range repeatables {
+			if max > 0 && len(result) >= max {
+				break
+			}
+			result = append(result, v)
+		}
+	}
+
+	m.logger().InfoContext(ctx, "plan computed", "direction", dir.String(), "migrations", len(result))
+
+	return result, nil
+}
+
+// PlanTo plans a migration to the migration with the given ID. If targetID
+// was not applied yet, every pending migration up to and including targetID
+// is planned in the Up direction. Otherwise, every applied migration after
+// targetID is planned in the Down direction.
+//
+// Unless migrations are rolled back, the repeatable migrations that changed
+// are planned after targetID is reached, as if it were the last migration.
+func (m *Migrator) PlanTo(src Source, targetID string) ([]*PlannedMigration, error) {
+	return m.PlanToContext(context.Background(), src, targetID)
+}
+
+// PlanToContext is like PlanTo, but uses ctx to read the applied migrations
+// from the database.
+func (m *Migrator) PlanToContext(ctx context.Context, src Source, targetID string) ([]*PlannedMigration, error) {
+	migrations, existingMigrations, repeatables, err := m.load(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *Migration
+	for _, migration := range migrations {
+		if migration.ID == targetID {
+			target = migration
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMigration, targetID)
+	}
+
+	applied := make(map[string]bool, len(existingMigrations))
+	for _, existing := range existingMigrations {
+		applied[existing.ID] = true
+	}
+
+	result := make([]*PlannedMigration, 0)
+
+	if !applied[target.ID] {
+		for _, v := range migrations {
+			if target.Less(v) {
+				break
+			}
+			if !applied[v.ID] {
+				result = append(result, newPlannedMigration(v, Up))
+			}
+		}
+	} else {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			v := migrations[i]
+			if v.ID == target.ID {
+				break
+			}
+			if applied[v.ID] {
+				result = append(result, newPlannedMigration(v, Down))
+			}
 		}
 	}
 
+	// Repeatable migrations come after the target, unless rolling back.
+	if len(result) == 0 || result[0].Direction == Up {
+		result = append(result, repeatables...)
+	}
+
+	m.logger().InfoContext(ctx, "plan computed", "target", targetID, "migrations", len(result))
+
 	return result, nil
 }
 
+// ExecTo migrates the database to the migration with the given ID, in either
+// direction, and returns the number of applied migrations. See PlanTo.
+func (m *Migrator) ExecTo(src Source, targetID string) (int, error) {
+	return m.ExecToContext(context.Background(), src, targetID)
+}
+
+// ExecToContext is like ExecTo, but uses ctx for all database operations.
+func (m *Migrator) ExecToContext(ctx context.Context, src Source, targetID string) (int, error) {
+	migrations, err := m.ExecToMigrationsContext(ctx, src, targetID)
+	return len(migrations), err
+}
+
+// ExecToMigrationsContext is like ExecToContext, but returns the applied
+// migrations instead of their number.
+func (m *Migrator) ExecToMigrationsContext(ctx context.Context, src Source, targetID string) ([]*PlannedMigration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	migrations, err := m.PlanToContext(ctx, src, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A plan to a target is either entirely up or entirely down.
+	op := OperationUp
+	if len(migrations) > 0 {
+		op = operationOf(migrations[0].Direction)
+	}
+
+	return m.execute(ctx, migrations, op)
+}
+
+// load finds the migrations in src and the migrations applied to the
+// database, both sorted by ID, and makes sure the latter are consistent with
+// the former. Repeatable migrations are left out of both, and returned
+// separately if they need to be applied.
+func (m *Migrator) load(ctx context.Context, src Source) ([]*Migration, []*Migration, []*PlannedMigration, error) {
+	found, err := src.Find()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	records, err := m.DB.Records(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	records = ResolveSquashedRecords(found, records)
+
+	// Make sure all migrations in the database are among the found migrations which
+	// are to be applied.
+	migrationsSearch := make(map[string]*Migration)
+	for _, migration := range found {
+		migrationsSearch[migration.ID] = migration
+	}
+	for _, record := range records {
+		if _, ok := migrationsSearch[record.ID]; !ok {
+			return nil, nil, nil, newPlanError(&Migration{ID: record.ID}, ErrUnknownMigration, "unknown migration in database")
+		}
+	}
+
+	// Refuse to plan anything on top of a partially applied migration.
+	for _, record := range records {
+		if record.Dirty {
+			return nil, nil, nil, newPlanError(migrationsSearch[record.ID], ErrDirty,
+				"migration is dirty, it failed halfway without a transaction; fix the database and resolve with force")
+		}
+	}
+
+	// Make sure none of the applied migrations were modified after they were
+	// applied.
+	for _, record := range records {
+		if migration := migrationsSearch[record.ID]; checksumMismatch(migration, record) {
+			return nil, nil, nil, newPlanError(migration, ErrChecksumMismatch, "checksum mismatch, migration was modified after it was applied")
+		}
+	}
+
+	recordsSearch := make(map[string]*Record)
+	for _, record := range records {
+		recordsSearch[record.ID] = record
+	}
+
+	// Repeatable migrations are applied whenever they changed since they
+	// were last applied.
+	var (
+		migrations  []*Migration
+		repeatables []*PlannedMigration
+	)
+	for _, migration := range found {
+		if !migration.Repeatable {
+			migrations = append(migrations, migration)
+			continue
+		}
+
+		record, ok := recordsSearch[migration.ID]
+		if ok && record.Checksum == migration.Checksum() {
+			continue
+		}
+
+		planned := newPlannedMigration(migration, Up)
+		planned.recorded = ok
+		repeatables = append(repeatables, planned)
+	}
+
+	// Sort migrations that have been run by ID.
+	var existingMigrations []*Migration
+	for _, record := range records {
+		if migrationsSearch[record.ID].Repeatable {
+			continue
+		}
+		existingMigrations = append(existingMigrations, &Migration{
+			ID: record.ID,
+		})
+	}
+	sort.Sort(byID(existingMigrations))
+
+	return migrations, existingMigrations, repeatables, nil
+}
+
 // Skip a set of migrations
 //
 // Will skip at most `max` migrations. Pass 0 for no limit.
 //
 // Returns the number of skipped migrations.
 func (m *Migrator) SkipMax(src Source, dir Direction, max int) (int, error) {
-	migrations, err := m.Plan(src, dir, max)
+	return m.SkipMaxContext(context.Background(), src, dir, max)
+}
+
+// SkipMaxContext is like SkipMax, but uses ctx for all database operations.
+func (m *Migrator) SkipMaxContext(ctx context.Context, src Source, dir Direction, max int) (int, error) {
+	migrations, err := m.SkipMaxMigrationsContext(ctx, src, dir, max)
+	return len(migrations), err
+}
+
+// SkipMaxMigrationsContext is like SkipMaxContext, but returns the skipped
+// migrations instead of their number. They are planned while holding the
+// migration lock, so they are exactly the migrations that were skipped.
+func (m *Migrator) SkipMaxMigrationsContext(ctx context.Context, src Source, dir Direction, max int) ([]*PlannedMigration, error) {
+	unlock, err := m.lock(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	defer unlock()
+
+	migrations, err := m.PlanContext(ctx, src, dir, max)
+	if err != nil {
+		return nil, err
 	}
 
 	// Skip migrations
-	applied := 0
-	for _, migration := range migrations {
-		var executor SqlExecutor
+	for i, migration := range migrations {
+		start := time.Now()
+		err := m.skipMigration(ctx, migration)
+		historyErr := m.logHistory(ctx, OperationSkip, migration, start, err)
+		if err != nil {
+			return migrations[:i], err
+		}
 
-		if migration.DisableTransaction {
-			executor = m.DB
-		} else {
-			executor, err = m.DB.Begin()
-			if err != nil {
-				return applied, newTxError(migration, err)
-			}
+		if historyErr != nil {
+			return migrations[:i+1], historyErr
 		}
+	}
 
-		err = executor.InsertRecord(&Record{
-			ID:        migration.ID,
-			AppliedAt: time.Now(),
-		})
+	return migrations, nil
+}
 
-		if tx, ok := executor.(Tx); ok {
-			if err != nil {
-				tx.Rollback()
-				return applied, newTxError(migration, err)
-			}
-			if err := tx.Commit(); err != nil {
-				return applied, newTxError(migration, err)
-			}
-		} else {
-			if err != nil {
-				return applied, err
-			}
+// skipMigration records a single planned migration as applied without
+// executing it.
+func (m *Migrator) skipMigration(ctx context.Context, migration *PlannedMigration) error {
+	var (
+		executor SqlExecutor
+		err      error
+	)
+
+	if migration.DisableTransaction {
+		executor = m.DB
+	} else {
+		executor, err = m.DB.Begin(ctx)
+		if err != nil {
+			return newTxError(migration, err)
 		}
+	}
 
-		applied++
+	if migration.recorded {
+		err = executor.UpdateRecord(ctx, m.newRecord(migration, 0))
+	} else {
+		err = executor.InsertRecord(ctx, m.newRecord(migration, 0))
+	}
+
+	if tx, ok := executor.(Tx); ok {
+		if err != nil {
+			rollback(tx)
+			return newTxError(migration, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			rollback(tx)
+			return newTxError(migration, err)
+		}
+	}
+
+	return err
+}
+
+// Redo rolls back the last applied migration and applies it again, and
+// returns it. It returns nil if no migration was applied.
+func (m *Migrator) Redo(src Source) (*Migration, error) {
+	return m.RedoContext(context.Background(), src)
+}
+
+// RedoContext is like Redo, but uses ctx for all database operations.
+func (m *Migrator) RedoContext(ctx context.Context, src Source) (*Migration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	redo, err := m.PlanRedoContext(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(redo) == 0 {
+		return nil, nil
 	}
+	migration := redo[0].Migration
 
-	return applied, nil
+	// Both directions are executed in a single run, so that the hooks run
+	// once.
+	if _, err := m.execute(ctx, redo, OperationRedo); err != nil {
+		return migration, err
+	}
+
+	return migration, nil
+}
+
+// PlanRedoContext returns the migrations RedoContext would execute: the last
+// applied migration in the Down direction, followed by the same migration in
+// the Up direction. It returns no migrations if none was applied.
+func (m *Migrator) PlanRedoContext(ctx context.Context, src Source) ([]*PlannedMigration, error) {
+	migrations, err := m.PlanContext(ctx, src, Down, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// The plan starts with the pending migrations preceding the last applied
+	// one, which are caught up in the Up direction and not redone.
+	for _, planned := range migrations {
+		if planned.Direction == Down {
+			return []*PlannedMigration{planned, newPlannedMigration(planned.Migration, Up)}, nil
+		}
+	}
+
+	return nil, nil
 }
 
 func (m *Migrator) Close() {
 	m.DB.Close()
 }
 
+// newRecord returns the record of mig having been executed in duration.
+func (m *Migrator) newRecord(mig *PlannedMigration, duration time.Duration) *Record {
+	hostname, _ := os.Hostname()
+
+	var username string
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	return &Record{
+		ID:          mig.ID,
+		AppliedAt:   time.Now(),
+		Checksum:    mig.Checksum(),
+		Direction:   mig.Direction,
+		Duration:    duration,
+		AppliedBy:   username,
+		Hostname:    hostname,
+		ToolVersion: m.ToolVersion,
+	}
+}
+
+// markDirty records that the migration of record is being executed outside of
+// a transaction. recorded is set if the migration has a record already.
+func markDirty(ctx context.Context, executor SqlExecutor, record *Record, recorded bool) error {
+	record.Dirty = true
+
+	if record.Direction == Up && !recorded {
+		return executor.InsertRecord(ctx, record)
+	}
+	return executor.UpdateRecord(ctx, record)
+}
+
+// rollback aborts tx. The context the transaction was started with is
+// deliberately not used, as it is usually the reason for the rollback and may
+// already be cancelled, which would prevent the rollback from being sent.
+func rollback(tx Tx) {
+	_ = tx.Rollback(context.Background())
+}
+
 // Filter a slice of migrations into ones that should be applied.
 func toApply(migrations []*Migration, current string, dir Direction) []*Migration {
 	var index = -1
@@ -302,11 +869,7 @@ func toCatchup(migrations, existingMigrations []*Migration, lastRun *Migration)
 			}
 		}
 		if !found && migration.Less(lastRun) {
-			missing = append(missing, &PlannedMigration{
-				Migration:          migration,
-				Queries:            migration.Up,
-				DisableTransaction: migration.DisableTransactionUp,
-			})
+			missing = append(missing, newPlannedMigration(migration, Up))
 		}
 	}
 	return missing
@@ -0,0 +1,387 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+var sqlMigrations = []*Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_alter_table",
+		Up:   []string{"ALTER TABLE people ADD COLUMN first_name text"},
+		Down: []string{"SELECT 0"},
+	},
+	{
+		ID:   "3_add_index",
+		Up:   []string{"CREATE INDEX people_first_name ON people (first_name)"},
+		Down: []string{"DROP INDEX people_first_name"},
+	},
+}
+
+type MigrateSuite struct {
+	db       *memDB
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&MigrateSuite{})
+
+func (s *MigrateSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.migrator = &Migrator{DB: s.db}
+	s.source = &MemorySource{Migrations: sqlMigrations}
+}
+
+func (s *MigrateSuite) TestExecUpDown(c *C) {
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+
+	n, err = s.migrator.ExecMax(s.source, Down, 2)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	records, err = s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+}
+
+func (s *MigrateSuite) TestExecFailureRollsBack(c *C) {
+	s.source.Migrations = append(s.source.Migrations[:2:2], &Migration{
+		ID: "3_broken",
+		Up: []string{"CREATE TABLE broken (id int)", "FAIL"},
+	})
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, FitsTypeOf, &TxError{})
+	c.Assert(n, Equals, 2)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(s.db.log, DeepEquals, []string{sqlMigrations[0].Up[0], sqlMigrations[1].Up[0]})
+}
+
+func (s *MigrateSuite) TestExecMaxMigrations(c *C) {
+	s.source.Migrations = append(s.source.Migrations[:2:2], &Migration{
+		ID: "3_broken",
+		Up: []string{"FAIL"},
+	})
+
+	migrations, err := s.migrator.ExecMaxMigrationsContext(context.Background(), s.source, Up, 0)
+	c.Assert(err, FitsTypeOf, &TxError{})
+	c.Assert(plannedIDs(migrations), DeepEquals, []string{"1_create_table up", "2_alter_table up"})
+
+	migrations, err = s.migrator.ExecToMigrationsContext(context.Background(), s.source, "1_create_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(migrations), DeepEquals, []string{"2_alter_table down"})
+}
+
+func (s *MigrateSuite) TestSkipMaxMigrations(c *C) {
+	migrations, err := s.migrator.SkipMaxMigrationsContext(context.Background(), s.source, Up, 2)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(migrations), DeepEquals, []string{"1_create_table up", "2_alter_table up"})
+	c.Assert(s.db.log, HasLen, 0)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+}
+
+func (s *MigrateSuite) TestExecContextCancelled(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel while the second migration is running.
+	s.db.beforeExec = func(stmt string) {
+		if stmt == sqlMigrations[1].Up[0] {
+			cancel()
+		}
+	}
+
+	n, err := s.migrator.ExecContext(ctx, s.source, Up)
+	c.Assert(err, FitsTypeOf, &TxError{})
+	c.Assert(err.(*TxError).Err, Equals, context.Canceled)
+	c.Assert(err.(*TxError).Migration.ID, Equals, "2_alter_table")
+	c.Assert(n, Equals, 1)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+	c.Assert(s.db.log, DeepEquals, []string{"CREATE TABLE people (id int)"})
+}
+
+func (s *MigrateSuite) TestPlanContextCancelled(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.migrator.PlanContext(ctx, s.source, Up, 0)
+	c.Assert(err, Equals, context.Canceled)
+}
+
+func plannedIDs(migrations []*PlannedMigration) []string {
+	ids := make([]string, 0, len(migrations))
+	for _, m := range migrations {
+		ids = append(ids, m.ID+" "+m.Direction.String())
+	}
+	return ids
+}
+
+func (s *MigrateSuite) TestPlanTo(c *C) {
+	plan, err := s.migrator.PlanTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"1_create_table up", "2_alter_table up"})
+
+	_, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+
+	plan, err = s.migrator.PlanTo(s.source, "1_create_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index down", "2_alter_table down"})
+	c.Assert(plan[0].Queries, DeepEquals, sqlMigrations[2].Down)
+
+	plan, err = s.migrator.PlanTo(s.source, "3_add_index")
+	c.Assert(err, IsNil)
+	c.Assert(plan, HasLen, 0)
+
+	_, err = s.migrator.PlanTo(s.source, "4_missing")
+	c.Assert(err, ErrorMatches, "unknown migration: 4_missing")
+	c.Assert(errors.Is(err, ErrUnknownMigration), Equals, true)
+}
+
+func (s *MigrateSuite) TestExecTo(c *C) {
+	n, err := s.migrator.ExecTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	n, err = s.migrator.ExecTo(s.source, "1_create_table")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].ID, Equals, "1_create_table")
+	c.Assert(s.db.log, DeepEquals, []string{
+		sqlMigrations[0].Up[0],
+		sqlMigrations[1].Up[0],
+		sqlMigrations[1].Down[0],
+	})
+}
+
+func (s *MigrateSuite) TestPlanToGap(c *C) {
+	// 2_alter_table was merged after 3_add_index was applied.
+	s.db.records["1_create_table"] = &Record{ID: "1_create_table"}
+	s.db.records["3_add_index"] = &Record{ID: "3_add_index"}
+
+	plan, err := s.migrator.PlanTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"2_alter_table up"})
+
+	plan, err = s.migrator.PlanTo(s.source, "3_add_index")
+	c.Assert(err, IsNil)
+	c.Assert(plan, HasLen, 0)
+
+	// The Down script of 2_alter_table is not run, as it was never applied.
+	plan, err = s.migrator.PlanTo(s.source, "1_create_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index down"})
+}
+
+func (s *MigrateSuite) TestRedoGap(c *C) {
+	// 2_alter_table was merged after 3_add_index was applied.
+	s.db.records["1_create_table"] = &Record{ID: "1_create_table"}
+	s.db.records["3_add_index"] = &Record{ID: "3_add_index"}
+
+	plan, err := s.migrator.PlanRedoContext(context.Background(), s.source)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index down", "3_add_index up"})
+
+	redone, err := s.migrator.Redo(s.source)
+	c.Assert(err, IsNil)
+	c.Assert(redone.ID, Equals, "3_add_index")
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[1].ID, Equals, "3_add_index")
+	c.Assert(s.db.log, DeepEquals, []string{sqlMigrations[2].Down[0], sqlMigrations[2].Up[0]})
+}
+
+func (s *MigrateSuite) TestPlanToRepeatable(c *C) {
+	s.source.Migrations = append(sqlMigrations[:3:3], s.repeatable("CREATE VIEW v1"))
+
+	plan, err := s.migrator.PlanTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"1_create_table up", "2_alter_table up", "people_view up"})
+
+	_, err = s.migrator.ExecTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+
+	// Changed, applied again although the target was reached already.
+	s.source.Migrations[3] = s.repeatable("CREATE VIEW v2")
+
+	plan, err = s.migrator.PlanTo(s.source, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"people_view up"})
+
+	// Not applied while rolling back.
+	plan, err = s.migrator.PlanTo(s.source, "1_create_table")
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"2_alter_table down"})
+}
+
+func (s *MigrateSuite) TestGoMigrations(c *C) {
+	var goSrc GoSource
+	goSrc.Register("2_backfill",
+		func(ctx context.Context, ex SqlExecutor) error {
+			_, err := ex.Exec(ctx, "UPDATE people SET first_name = 'unknown'")
+			return err
+		},
+		func(ctx context.Context, ex SqlExecutor) error {
+			_, err := ex.Exec(ctx, "UPDATE people SET first_name = NULL")
+			return err
+		},
+	)
+	src := MultiSource{s.source, &goSrc}
+
+	n, err := s.migrator.Exec(src, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 4)
+	c.Assert(s.db.log[2], Equals, "UPDATE people SET first_name = 'unknown'")
+
+	n, err = s.migrator.ExecTo(src, "2_alter_table")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.db.log[len(s.db.log)-1], Equals, "UPDATE people SET first_name = NULL")
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+}
+
+func (s *MigrateSuite) TestGoMigrationFailureRollsBack(c *C) {
+	var goSrc GoSource
+	goSrc.Register("4_broken", func(ctx context.Context, ex SqlExecutor) error {
+		if _, err := ex.Exec(ctx, "DELETE FROM people"); err != nil {
+			return err
+		}
+		return errors.New("backfill failed")
+	}, nil)
+
+	n, err := s.migrator.Exec(MultiSource{s.source, &goSrc}, Up)
+	c.Assert(err, ErrorMatches, "backfill failed handling 4_broken")
+	c.Assert(n, Equals, 3)
+	c.Assert(s.db.log, HasLen, 3)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+}
+
+func (s *MigrateSuite) TestRecordMetadata(c *C) {
+	s.migrator.ToolVersion = "1.2.3"
+
+	_, err := s.migrator.ExecMax(s.source, Up, 1)
+	c.Assert(err, IsNil)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Direction, Equals, Up)
+	c.Assert(records[0].ToolVersion, Equals, "1.2.3")
+	c.Assert(records[0].Duration >= 0, Equals, true)
+	c.Assert(records[0].Dirty, Equals, false)
+}
+
+func (s *MigrateSuite) TestParseDirection(c *C) {
+	for _, dir := range []Direction{Up, Down} {
+		parsed, err := ParseDirection(dir.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, dir)
+	}
+
+	_, err := ParseDirection("sideways")
+	c.Assert(err, ErrorMatches, "unknown direction: sideways")
+}
+
+func (s *MigrateSuite) repeatable(query string) *Migration {
+	return &Migration{
+		ID:         "people_view",
+		Up:         []string{query},
+		Repeatable: true,
+	}
+}
+
+func (s *MigrateSuite) TestRepeatable(c *C) {
+	ctx := context.Background()
+	s.source.Migrations = append(s.source.Migrations[:2:2], s.repeatable("CREATE VIEW v1"))
+
+	plan, err := s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"1_create_table up", "2_alter_table up", "people_view up"})
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+
+	// Unchanged, nothing to do.
+	n, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 0)
+
+	// Changed, applied again after the new versioned migration.
+	s.source.Migrations = append(sqlMigrations[:3:3], s.repeatable("CREATE VIEW v2"))
+
+	plan, err = s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index up", "people_view up"})
+
+	n, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.db.log[len(s.db.log)-1], Equals, "CREATE VIEW v2")
+
+	records, err := s.db.Records(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 4)
+	for _, record := range records {
+		if record.ID == "people_view" {
+			c.Assert(record.Checksum, Equals, s.source.Migrations[3].Checksum())
+		}
+	}
+
+	errs, err := s.migrator.Verify(ctx, s.source)
+	c.Assert(err, IsNil)
+	c.Assert(errs, HasLen, 0)
+
+	// Never rolled back.
+	plan, err = s.migrator.Plan(s.source, Down, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index down", "2_alter_table down", "1_create_table down"})
+}
+
+func (s *MigrateSuite) TestRepeatableAfterPendingMigrations(c *C) {
+	s.source.Migrations = append(sqlMigrations[:3:3], s.repeatable("CREATE VIEW v1"))
+
+	plan, err := s.migrator.Plan(s.source, Up, 2)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"1_create_table up", "2_alter_table up"})
+
+	plan, err = s.migrator.Plan(s.source, Up, 4)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"1_create_table up", "2_alter_table up", "3_add_index up", "people_view up"})
+}
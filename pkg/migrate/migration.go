@@ -1,6 +1,9 @@
 package migrate
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -8,14 +11,48 @@ import (
 
 var numberPrefixRegex = regexp.MustCompile(`^(\d+).*$`)
 
+// MigrationFunc is a migration written in Go. ex is the transaction the
+// migration runs in, or the DB if transactions are disabled for it.
+type MigrationFunc func(ctx context.Context, ex SqlExecutor) error
+
 type Migration struct {
 	ID string
 
 	Up   []string
 	Down []string
 
+	// UpLines and DownLines are the lines of the migration file the Up and
+	// Down statements start at, if known. They locate failed statements in
+	// TxErrors.
+	UpLines   []int
+	DownLines []int
+
+	// UpFunc and DownFunc are run after the Up and Down statements
+	// respectively, if set.
+	UpFunc   MigrationFunc
+	DownFunc MigrationFunc
+
 	DisableTransactionUp   bool
 	DisableTransactionDown bool
+
+	// Repeatable migrations are applied again whenever their checksum
+	// changes, after all other migrations. They are never rolled back.
+	Repeatable bool
+
+	// Squashes lists the IDs of the migrations squashed into this one. See
+	// Squash.
+	Squashes []string
+}
+
+// Checksum returns a digest of the Up statements of the migration, used to
+// detect migrations that were modified after they were applied.
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	for _, stmt := range m.Up {
+		h.Write([]byte(stmt))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func (m Migration) Less(other *Migration) bool {
@@ -57,6 +94,40 @@ func (b byID) Less(i, j int) bool { return b[i].Less(b[j]) }
 type PlannedMigration struct {
 	*Migration
 
+	Direction          Direction
 	DisableTransaction bool
 	Queries            []string
+	Func               MigrationFunc
+
+	// Lines are the lines of the migration file Queries start at, if known.
+	Lines []int
+
+	// recorded is set if the migration has a record already, as do
+	// repeatable migrations being applied again.
+	recorded bool
+}
+
+func newPlannedMigration(migration *Migration, dir Direction) *PlannedMigration {
+	switch dir {
+	case Up:
+		return &PlannedMigration{
+			Migration:          migration,
+			Direction:          Up,
+			Queries:            migration.Up,
+			Lines:              migration.UpLines,
+			Func:               migration.UpFunc,
+			DisableTransaction: migration.DisableTransactionUp,
+		}
+	case Down:
+		return &PlannedMigration{
+			Migration:          migration,
+			Direction:          Down,
+			Queries:            migration.Down,
+			Lines:              migration.DownLines,
+			Func:               migration.DownFunc,
+			DisableTransaction: migration.DisableTransactionDown,
+		}
+	}
+
+	panic(fmt.Sprintf("unexpected direction: %v", dir))
 }
@@ -1,8 +1,12 @@
 package migrate
 
 import (
+	"bytes"
 	"fmt"
-	"net/http"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -31,41 +35,126 @@ func (m MemorySource) Find() ([]*Migration, error) {
 	return migrations, nil
 }
 
+// A set of migrations written in Go.
+type GoSource struct {
+	migrations []*Migration
+}
+
+var _ Source = (*GoSource)(nil)
+
+// Register adds a migration with the given ID, running up when applied and
+// down when rolled back. Either may be nil. Register panics if a migration
+// with the same ID was already registered.
+//
+// The returned migration may be modified further, e.g. to disable
+// transactions, before the source is used.
+func (g *GoSource) Register(id string, up, down MigrationFunc) *Migration {
+	for _, m := range g.migrations {
+		if m.ID == id {
+			panic(fmt.Sprintf("migrate: migration %s registered twice", id))
+		}
+	}
+
+	m := &Migration{
+		ID:       id,
+		UpFunc:   up,
+		DownFunc: down,
+	}
+	g.migrations = append(g.migrations, m)
+
+	return m
+}
+
+func (g *GoSource) Find() ([]*Migration, error) {
+	return MemorySource{Migrations: g.migrations}.Find()
+}
+
+// MultiSource combines the migrations found in several sources, for example
+// a FileSource and a GoSource, into a single ordered set.
+type MultiSource []Source
+
+var _ Source = MultiSource(nil)
+
+func (s MultiSource) Find() ([]*Migration, error) {
+	var migrations []*Migration
+	seen := make(map[string]struct{})
+
+	for _, src := range s {
+		found, err := src.Find()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range found {
+			if _, ok := seen[m.ID]; ok {
+				return nil, fmt.Errorf("duplicate migration %s", m.ID)
+			}
+			seen[m.ID] = struct{}{}
+		}
+
+		migrations = append(migrations, found...)
+	}
+
+	sort.Sort(byID(migrations))
+
+	return migrations, nil
+}
+
 // A set of migrations loaded from a directory.
 type FileSource struct {
+	// Dir is the directory of the migrations. Empty means the current
+	// directory.
 	Dir string
 }
 
 var _ Source = (*FileSource)(nil)
 
 func (f FileSource) Find() ([]*Migration, error) {
-	filesystem := http.Dir(f.Dir)
-	return findMigrations(filesystem)
+	dir := f.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	return findMigrations(os.DirFS(dir), ".")
 }
 
-func findMigrations(dir http.FileSystem) ([]*Migration, error) {
-	migrations := make([]*Migration, 0)
+// A set of migrations loaded from a file system, such as an embed.FS.
+type FSSource struct {
+	FS fs.FS
+	// Dir is the directory within FS containing the migrations. Defaults to
+	// the root of FS.
+	Dir string
+}
 
-	file, err := dir.Open("/")
-	if err != nil {
-		return nil, err
+var _ Source = (*FSSource)(nil)
+
+func (f FSSource) Find() ([]*Migration, error) {
+	dir := f.Dir
+	if dir == "" {
+		dir = "."
 	}
 
-	files, err := file.Readdir(0)
+	return findMigrations(f.FS, dir)
+}
+
+func findMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	migrations := make([]*Migration, 0)
+
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, info := range files {
-		if strings.HasSuffix(info.Name(), ".sql") {
-			file, err := dir.Open(info.Name())
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
 			if err != nil {
-				return nil, fmt.Errorf("error opening %s: %s", info.Name(), err)
+				return nil, fmt.Errorf("error reading %s: %w", entry.Name(), err)
 			}
 
-			migration, err := parse(info.Name(), file)
+			migration, err := parse(entry.Name(), bytes.NewReader(content))
 			if err != nil {
-				return nil, fmt.Errorf("error parsing %s: %s", info.Name(), err)
+				return nil, fmt.Errorf("error parsing %s: %w", entry.Name(), err)
 			}
 
 			migrations = append(migrations, migration)
@@ -77,3 +166,14 @@ func findMigrations(dir http.FileSystem) ([]*Migration, error) {
 
 	return migrations, nil
 }
+
+// ParseFile parses the migration in the file at name, identified by the base
+// name of the file, for reading single files such as SQLHooks.
+func ParseFile(name string) (*Migration, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(filepath.Base(name), bytes.NewReader(content))
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"testing/fstest"
+
+	. "gopkg.in/check.v1"
+)
+
+type SourceSuite struct{}
+
+var _ = Suite(&SourceSuite{})
+
+func noop(ctx context.Context, ex SqlExecutor) error { return nil }
+
+func (s *SourceSuite) TestGoSource(c *C) {
+	var src GoSource
+	src.Register("2_backfill", noop, nil)
+	src.Register("1_seed", noop, noop).DisableTransactionUp = true
+
+	migrations, err := src.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 2)
+	c.Assert(migrations[0].ID, Equals, "1_seed")
+	c.Assert(migrations[0].DisableTransactionUp, Equals, true)
+	c.Assert(migrations[1].ID, Equals, "2_backfill")
+	c.Assert(migrations[1].DownFunc, IsNil)
+
+	c.Assert(func() { src.Register("1_seed", noop, noop) }, PanicMatches, ".*registered twice")
+}
+
+func (s *SourceSuite) TestMultiSource(c *C) {
+	var goSrc GoSource
+	goSrc.Register("2_backfill", noop, noop)
+
+	src := MultiSource{&MemorySource{Migrations: sqlMigrations}, &goSrc}
+
+	migrations, err := src.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 4)
+	c.Assert(migrations[0].ID, Equals, "1_create_table")
+	c.Assert(migrations[1].ID, Equals, "2_alter_table")
+	c.Assert(migrations[2].ID, Equals, "2_backfill")
+	c.Assert(migrations[3].ID, Equals, "3_add_index")
+
+	goSrc.Register("3_add_index", noop, noop)
+	_, err = src.Find()
+	c.Assert(err, ErrorMatches, "duplicate migration 3_add_index")
+}
+
+func (s *SourceSuite) TestMultiSourceError(c *C) {
+	src := MultiSource{&MemorySource{}, errSource{}}
+
+	_, err := src.Find()
+	c.Assert(err, ErrorMatches, "cannot find")
+}
+
+type errSource struct{}
+
+func (errSource) Find() ([]*Migration, error) { return nil, errors.New("cannot find") }
+
+const fsMigration = `
+-- +migrate Up
+CREATE TABLE people (id int);
+
+-- +migrate Down
+DROP TABLE people;
+`
+
+func (s *SourceSuite) TestFileSource(c *C) {
+	migrations, err := FileSource{Dir: "test-migrations"}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 2)
+	c.Assert(migrations[0].ID, Equals, "1_initial.sql")
+	c.Assert(migrations[0].Up, HasLen, 1)
+	c.Assert(migrations[1].ID, Equals, "2_record.sql")
+
+	_, err = FileSource{Dir: "missing-migrations"}.Find()
+	c.Assert(err, NotNil)
+}
+
+func (s *SourceSuite) TestFileSourceCurrentDir(c *C) {
+	wd, err := os.Getwd()
+	c.Assert(err, IsNil)
+	c.Assert(os.Chdir("test-migrations"), IsNil)
+	defer func() { c.Assert(os.Chdir(wd), IsNil) }()
+
+	migrations, err := FileSource{}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 2)
+}
+
+func (s *SourceSuite) TestFSSource(c *C) {
+	fsys := fstest.MapFS{
+		"10_people.sql":              {Data: []byte(fsMigration)},
+		"9_people.sql":               {Data: []byte(fsMigration)},
+		"README.md":                  {Data: []byte("not a migration")},
+		"postgres/1_people.sql":      {Data: []byte(fsMigration)},
+		"postgres/nested/2_skip.sql": {Data: []byte(fsMigration)},
+	}
+
+	migrations, err := FSSource{FS: fsys}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 2)
+	c.Assert(migrations[0].ID, Equals, "9_people.sql")
+	c.Assert(migrations[1].ID, Equals, "10_people.sql")
+	c.Assert(migrations[1].Down, HasLen, 1)
+
+	migrations, err = FSSource{FS: fsys, Dir: "postgres"}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 1)
+	c.Assert(migrations[0].ID, Equals, "1_people.sql")
+
+	sub, err := fs.Sub(fsys, "postgres")
+	c.Assert(err, IsNil)
+	migrations, err = FSSource{FS: sub}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 1)
+}
+
+//go:embed test-migrations
+var embeddedMigrations embed.FS
+
+func (s *SourceSuite) TestFSSourceEmbed(c *C) {
+	migrations, err := FSSource{FS: embeddedMigrations, Dir: "test-migrations"}.Find()
+	c.Assert(err, IsNil)
+	c.Assert(migrations, HasLen, 2)
+	c.Assert(migrations[0].ID, Equals, "1_initial.sql")
+	c.Assert(migrations[1].ID, Equals, "2_record.sql")
+}
+
+func (s *SourceSuite) TestFSSourceParseError(c *C) {
+	fsys := fstest.MapFS{
+		"1_broken.sql": {Data: []byte("CREATE TABLE people (id int);")},
+	}
+
+	_, err := FSSource{FS: fsys}.Find()
+	c.Assert(err, ErrorMatches, "(?s)error parsing 1_broken.sql: .*")
+}
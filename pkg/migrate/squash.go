@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+)
+
+// Squash combines migrations, in order, into a single migration with the
+// given ID. Its Up statements are those of migrations in order, and its Down
+// statements those of migrations in reverse order. Its Squashes lists the IDs
+// of migrations, and of the migrations they squashed in turn, so that the
+// databases having applied them treat it as applied.
+//
+// Migrations written in Go and repeatable migrations cannot be squashed, and
+// migrations running with and without a transaction cannot be squashed
+// together.
+func Squash(id string, migrations []*Migration) (*Migration, error) {
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations to squash: %w", ErrNoChange)
+	}
+
+	first := migrations[0]
+	squashed := &Migration{
+		ID:                     id,
+		DisableTransactionUp:   first.DisableTransactionUp,
+		DisableTransactionDown: first.DisableTransactionDown,
+	}
+
+	for _, migration := range migrations {
+		switch {
+		case migration.UpFunc != nil || migration.DownFunc != nil:
+			return nil, fmt.Errorf("cannot squash %s, it is written in Go", migration.ID)
+		case migration.Repeatable:
+			return nil, fmt.Errorf("cannot squash %s, it is repeatable", migration.ID)
+		case migration.DisableTransactionUp != first.DisableTransactionUp,
+			migration.DisableTransactionDown != first.DisableTransactionDown:
+			return nil, fmt.Errorf("cannot squash %s with %s, only one of them runs without a transaction",
+				first.ID, migration.ID)
+		}
+
+		squashed.Up = append(squashed.Up, migration.Up...)
+		squashed.Squashes = append(squashed.Squashes, migration.Squashes...)
+		squashed.Squashes = append(squashed.Squashes, migration.ID)
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		squashed.Down = append(squashed.Down, migrations[i].Down...)
+	}
+
+	return squashed, nil
+}
+
+// ResolveSquashedRecords returns records, with the records of the migrations
+// squashed into one of migrations replaced with a single record of the
+// migration they were squashed into. The latter is dirty if any of the former
+// is, and otherwise describes the last applied of them.
+//
+// A database having applied any of the squashed migrations is assumed to
+// have applied all of them, so only migrations applied to every database, or
+// to none, should be squashed.
+func ResolveSquashedRecords(migrations []*Migration, records []*Record) []*Record {
+	squashedInto := make(map[string]*Migration)
+	for _, migration := range migrations {
+		for _, id := range migration.Squashes {
+			squashedInto[id] = migration
+		}
+	}
+	if len(squashedInto) == 0 {
+		return records
+	}
+
+	recorded := make(map[string]bool)
+	for _, record := range records {
+		recorded[record.ID] = true
+	}
+
+	resolved := make(map[string]*Record)
+	result := make([]*Record, 0, len(records))
+	for _, record := range records {
+		migration, ok := squashedInto[record.ID]
+		if !ok {
+			result = append(result, record)
+			continue
+		}
+
+		// Leftovers of a migration recorded already.
+		if recorded[migration.ID] {
+			continue
+		}
+
+		r, ok := resolved[migration.ID]
+		if !ok {
+			r = &Record{
+				ID:        migration.ID,
+				Checksum:  migration.Checksum(),
+				Direction: Up,
+				Baseline:  true,
+			}
+			resolved[migration.ID] = r
+			result = append(result, r)
+		}
+
+		if record.AppliedAt.After(r.AppliedAt) {
+			r.AppliedAt = record.AppliedAt
+			r.AppliedBy = record.AppliedBy
+			r.Hostname = record.Hostname
+			r.ToolVersion = record.ToolVersion
+		}
+		r.Dirty = r.Dirty || record.Dirty
+		r.Duration += record.Duration
+		r.Baseline = r.Baseline && record.Baseline
+	}
+
+	return result
+}
+
+// adoptSquashed replaces the records of the migrations squashed into mig
+// with a record of mig, unless it has one already, before rolling it back.
+// records are the records of the database.
+func adoptSquashed(ctx context.Context, executor SqlExecutor, mig *PlannedMigration, records []*Record) error {
+	squashed := make(map[string]bool)
+	for _, id := range mig.Squashes {
+		squashed[id] = true
+	}
+
+	recorded := false
+	for _, record := range records {
+		recorded = recorded || record.ID == mig.ID
+	}
+
+	if !recorded {
+		for _, record := range ResolveSquashedRecords([]*Migration{mig.Migration}, records) {
+			if record.ID == mig.ID {
+				if err := executor.InsertRecord(ctx, record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, record := range records {
+		if squashed[record.ID] {
+			if err := executor.DeleteRecord(ctx, record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+)
+
+type SquashSuite struct {
+	db       *memDB
+	migrator *Migrator
+	source   *MemorySource
+}
+
+var _ = Suite(&SquashSuite{})
+
+func (s *SquashSuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.migrator = &Migrator{DB: s.db}
+	s.source = &MemorySource{Migrations: sqlMigrations}
+}
+
+func (s *SquashSuite) squash(c *C) {
+	squashed, err := Squash("2_alter_table-squashed", sqlMigrations[:2])
+	c.Assert(err, IsNil)
+	s.source = &MemorySource{Migrations: []*Migration{squashed, sqlMigrations[2]}}
+}
+
+func (s *SquashSuite) recordIDs(c *C) []string {
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func (s *SquashSuite) TestSquash(c *C) {
+	squashed, err := Squash("2_alter_table-squashed", sqlMigrations[:2])
+	c.Assert(err, IsNil)
+	c.Assert(squashed.Up, DeepEquals, []string{sqlMigrations[0].Up[0], sqlMigrations[1].Up[0]})
+	c.Assert(squashed.Down, DeepEquals, []string{sqlMigrations[1].Down[0], sqlMigrations[0].Down[0]})
+	c.Assert(squashed.Squashes, DeepEquals, []string{"1_create_table", "2_alter_table"})
+
+	nested, err := Squash("3_add_index-squashed", []*Migration{squashed, sqlMigrations[2]})
+	c.Assert(err, IsNil)
+	c.Assert(nested.Squashes, DeepEquals, []string{
+		"1_create_table", "2_alter_table", "2_alter_table-squashed", "3_add_index",
+	})
+}
+
+func (s *SquashSuite) TestSquashTransactions(c *C) {
+	noTx := &Migration{
+		ID:                   "3_concurrent_index",
+		Up:                   []string{"CREATE INDEX CONCURRENTLY people_id ON people (id)"},
+		DisableTransactionUp: true,
+	}
+
+	_, err := Squash("squashed", []*Migration{sqlMigrations[0], noTx})
+	c.Assert(err, ErrorMatches, "cannot squash 1_create_table with 3_concurrent_index, .*")
+
+	squashed, err := Squash("squashed", []*Migration{noTx})
+	c.Assert(err, IsNil)
+	c.Assert(squashed.DisableTransactionUp, Equals, true)
+}
+
+func (s *SquashSuite) TestAppliedBeforeSquash(c *C) {
+	_, err := s.migrator.ExecMax(s.source, Up, 2)
+	c.Assert(err, IsNil)
+
+	s.squash(c)
+
+	plan, err := s.migrator.Plan(s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(plannedIDs(plan), DeepEquals, []string{"3_add_index up"})
+
+	n, err := s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1)
+
+	// Rolling back the squashed migration replaces the records of the
+	// original migrations.
+	n, err = s.migrator.Exec(s.source, Down)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.recordIDs(c), HasLen, 0)
+	c.Assert(s.db.log[len(s.db.log)-2:], DeepEquals, []string{sqlMigrations[1].Down[0], sqlMigrations[0].Down[0]})
+
+	n, err = s.migrator.Exec(s.source, Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(s.recordIDs(c), DeepEquals, []string{"2_alter_table-squashed", "3_add_index"})
+}
+
+func (s *SquashSuite) TestResolveSquashedRecords(c *C) {
+	_, err := s.migrator.ExecMax(s.source, Up, 2)
+	c.Assert(err, IsNil)
+
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+
+	s.squash(c)
+
+	resolved := ResolveSquashedRecords(s.source.Migrations, records)
+	c.Assert(resolved, HasLen, 1)
+	c.Assert(resolved[0].ID, Equals, "2_alter_table-squashed")
+	c.Assert(resolved[0].Checksum, Equals, s.source.Migrations[0].Checksum())
+	c.Assert(resolved[0].AppliedAt, Equals, records[1].AppliedAt)
+	c.Assert(resolved[0].Baseline, Equals, false)
+
+	c.Assert(ResolveSquashedRecords(sqlMigrations, records), DeepEquals, records)
+}
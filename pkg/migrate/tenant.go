@@ -0,0 +1,228 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tenant is a target of a multi-tenant run: a database, or a schema of a
+// database, migrated with a record table of its own.
+type Tenant struct {
+	// Name identifies the tenant in reports.
+	Name    string
+	Dialect string
+	Config  *DBConfig
+}
+
+// SchemaTenants returns a tenant per schema of the database described by
+// config. Every tenant keeps its record table in its schema, which is also
+// prepended to the search path of its sessions, so that unqualified names in
+// migrations refer to its schema.
+func SchemaTenants(dialect string, config *DBConfig, schemas []string) []*Tenant {
+	tenants := make([]*Tenant, 0, len(schemas))
+	for _, schema := range schemas {
+		tenantConfig := *config
+		tenantConfig.Schema = schema
+		tenantConfig.SearchPath = quoteSchema(schema)
+		if config.SearchPath != "" {
+			tenantConfig.SearchPath += "," + config.SearchPath
+		}
+
+		tenants = append(tenants, &Tenant{
+			Name:    schema,
+			Dialect: dialect,
+			Config:  &tenantConfig,
+		})
+	}
+
+	return tenants
+}
+
+// quoteSchema quotes schema for use in a search path.
+func quoteSchema(schema string) string {
+	return `"` + strings.ReplaceAll(schema, `"`, `""`) + `"`
+}
+
+// StringQuerier is implemented by DBs able to run a query returning a single
+// column of strings.
+type StringQuerier interface {
+	QueryStrings(ctx context.Context, query string) ([]string, error)
+}
+
+// DiscoverSchemaTenants runs query on the database described by config and
+// returns the SchemaTenants of the schemas it returns, one per row.
+func DiscoverSchemaTenants(ctx context.Context, dialect string, config *DBConfig, query string) ([]*Tenant, error) {
+	db, err := getDB(ctx, dialect, &DBConfig{Datasource: config.Datasource})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	querier, ok := db.(StringQuerier)
+	if !ok {
+		return nil, fmt.Errorf("tenant discovery not supported")
+	}
+
+	schemas, err := querier.QueryStrings(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error discovering tenants: %w", err)
+	}
+
+	return SchemaTenants(dialect, config, schemas), nil
+}
+
+// TenantFunc is called by MultiMigrator.Run with the Migrator of a tenant and
+// returns the number of migrations it applied.
+type TenantFunc func(ctx context.Context, tenant *Tenant, m *Migrator) (int, error)
+
+// TenantResult is the outcome of migrating a tenant.
+type TenantResult struct {
+	Tenant *Tenant
+	// Applied is the number of migrations applied.
+	Applied  int
+	Duration time.Duration
+	Err      error
+	// Skipped is set if the tenant was not migrated because another tenant
+	// failed.
+	Skipped bool
+}
+
+// MultiMigrator applies the same migrations to many tenants, each with a
+// Migrator of its own.
+type MultiMigrator struct {
+	Tenants []*Tenant
+	// Parallelism is the maximum number of tenants migrated concurrently.
+	// Tenants are migrated one at a time if zero.
+	Parallelism int
+	// ContinueOnError keeps migrating the remaining tenants once one failed.
+	// Otherwise the tenants not started yet are skipped.
+	ContinueOnError bool
+	// Setup, if set, is called with the Migrator of every tenant before it is
+	// migrated, e.g. to set its LockTimeout.
+	Setup func(ctx context.Context, tenant *Tenant, m *Migrator) error
+}
+
+// ExecMax applies at most max migrations of src in dir to every tenant. See
+// Run for the returned results and error.
+func (mm *MultiMigrator) ExecMax(ctx context.Context, src Source, dir Direction, max int) ([]*TenantResult, error) {
+	return mm.Run(ctx, func(ctx context.Context, tenant *Tenant, m *Migrator) (int, error) {
+		return m.ExecMaxContext(ctx, src, dir, max)
+	})
+}
+
+// Run calls f with the Migrator of every tenant. Returns the result of every
+// tenant, in the order of Tenants, and an error if any tenant failed.
+func (mm *MultiMigrator) Run(ctx context.Context, f TenantFunc) ([]*TenantResult, error) {
+	parallelism := mm.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+		sem    = make(chan struct{}, parallelism)
+	)
+
+	results := make([]*TenantResult, len(mm.Tenants))
+	for i, tenant := range mm.Tenants {
+		results[i] = &TenantResult{Tenant: tenant}
+	}
+
+	for _, result := range results {
+		sem <- struct{}{}
+
+		mu.Lock()
+		skip := failed && !mm.ContinueOnError
+		mu.Unlock()
+		if skip || ctx.Err() != nil {
+			<-sem
+			result.Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *TenantResult) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			start := time.Now()
+			result.Applied, result.Err = mm.runTenant(ctx, result.Tenant, f)
+			result.Duration = time.Since(start)
+
+			if result.Err != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(result)
+	}
+
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	if len(errs) > 0 {
+		return results, &TenantsError{Errs: errs, Tenants: len(results)}
+	}
+
+	return results, ctx.Err()
+}
+
+// TenantsError is returned by MultiMigrator.Run when tenants failed. It
+// matches the errors of the failed tenants with errors.Is and errors.As.
+type TenantsError struct {
+	// Errs are the errors of the failed tenants.
+	Errs []error
+	// Tenants is the number of tenants.
+	Tenants int
+}
+
+func (e *TenantsError) Error() string {
+	return fmt.Sprintf("%d of %d tenants failed", len(e.Errs), e.Tenants)
+}
+
+// Is reports whether the error of any failed tenant matches target.
+func (e *TenantsError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the failed tenants matching target.
+func (e *TenantsError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (mm *MultiMigrator) runTenant(ctx context.Context, tenant *Tenant, f TenantFunc) (int, error) {
+	m, err := NewFromConfig(ctx, tenant.Dialect, tenant.Config)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	if mm.Setup != nil {
+		if err := mm.Setup(ctx, tenant, m); err != nil {
+			return 0, err
+		}
+	}
+
+	return f(ctx, tenant, m)
+}
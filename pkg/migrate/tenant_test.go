@@ -0,0 +1,155 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+// memDialect creates a memDB per data source and schema, and returns the
+// existing one when connecting to it again.
+type memDialect struct {
+	*memDB
+	mu  sync.Mutex
+	dbs map[string]*memDB
+}
+
+func (d *memDialect) New(ctx context.Context, config *DBConfig) (DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := config.Datasource + "/" + config.Schema
+	db, ok := d.dbs[key]
+	if !ok {
+		db = newMemDB()
+		d.dbs[key] = db
+	}
+
+	return db, nil
+}
+
+type TenantSuite struct {
+	dialect *memDialect
+	multi   *MultiMigrator
+	source  *MemorySource
+}
+
+var _ = Suite(&TenantSuite{})
+
+func (s *TenantSuite) SetUpTest(c *C) {
+	s.dialect = &memDialect{dbs: make(map[string]*memDB)}
+	RegisterDB("memdb", s.dialect)
+
+	s.multi = &MultiMigrator{Parallelism: 2}
+	for _, ds := range []string{"a", "b", "c"} {
+		s.multi.Tenants = append(s.multi.Tenants, &Tenant{
+			Name:    ds,
+			Dialect: "memdb",
+			Config:  &DBConfig{Datasource: ds, TableName: "migrations"},
+		})
+	}
+
+	s.source = &MemorySource{Migrations: sqlMigrations}
+}
+
+func (s *TenantSuite) records(c *C, datasource string) int {
+	db, ok := s.dialect.dbs[datasource+"/"]
+	if !ok {
+		return 0
+	}
+
+	records, err := db.Records(context.Background())
+	c.Assert(err, IsNil)
+	return len(records)
+}
+
+func (s *TenantSuite) TestExecMax(c *C) {
+	results, err := s.multi.ExecMax(context.Background(), s.source, Up, 0)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 3)
+
+	for i, name := range []string{"a", "b", "c"} {
+		c.Assert(results[i].Tenant.Name, Equals, name)
+		c.Assert(results[i].Err, IsNil)
+		c.Assert(results[i].Applied, Equals, 3)
+		c.Assert(s.records(c, name), Equals, 3)
+	}
+
+	results, err = s.multi.ExecMax(context.Background(), s.source, Down, 1)
+	c.Assert(err, IsNil)
+	for _, result := range results {
+		c.Assert(result.Applied, Equals, 1)
+	}
+	c.Assert(s.records(c, "b"), Equals, 2)
+}
+
+func (s *TenantSuite) failTenant(name string) {
+	s.multi.Setup = func(ctx context.Context, tenant *Tenant, m *Migrator) error {
+		if tenant.Name == name {
+			return errors.New("setup failed")
+		}
+		return nil
+	}
+}
+
+func (s *TenantSuite) TestStopOnError(c *C) {
+	s.multi.Parallelism = 1
+	s.failTenant("b")
+
+	results, err := s.multi.ExecMax(context.Background(), s.source, Up, 0)
+	c.Assert(err, ErrorMatches, "1 of 3 tenants failed")
+
+	var tenantsErr *TenantsError
+	c.Assert(errors.As(err, &tenantsErr), Equals, true)
+	c.Assert(tenantsErr.Errs, DeepEquals, []error{results[1].Err})
+
+	c.Assert(results[0].Applied, Equals, 3)
+	c.Assert(results[1].Err, ErrorMatches, "setup failed")
+	c.Assert(results[2].Skipped, Equals, true)
+	c.Assert(s.records(c, "c"), Equals, 0)
+}
+
+func (s *TenantSuite) TestTenantsErrorMatches(c *C) {
+	txErr := &TxError{Migration: sqlMigrations[0], Err: errors.New("syntax error"), Statement: -1}
+	var err error = &TenantsError{
+		Errs:    []error{txErr, newPlanError(sqlMigrations[1], ErrDirty, "dirty")},
+		Tenants: 3,
+	}
+	c.Assert(errors.Is(err, ErrDirty), Equals, true)
+	c.Assert(errors.Is(err, ErrLocked), Equals, false)
+
+	var asTxErr *TxError
+	c.Assert(errors.As(err, &asTxErr), Equals, true)
+	c.Assert(asTxErr, Equals, txErr)
+}
+
+func (s *TenantSuite) TestContinueOnError(c *C) {
+	s.multi.Parallelism = 1
+	s.multi.ContinueOnError = true
+	s.failTenant("b")
+
+	results, err := s.multi.ExecMax(context.Background(), s.source, Up, 0)
+	c.Assert(err, ErrorMatches, "1 of 3 tenants failed")
+
+	c.Assert(results[1].Err, NotNil)
+	c.Assert(results[2].Skipped, Equals, false)
+	c.Assert(results[2].Applied, Equals, 3)
+	c.Assert(s.records(c, "c"), Equals, 3)
+}
+
+func (s *TenantSuite) TestSchemaTenants(c *C) {
+	config := &DBConfig{Datasource: "db", TableName: "migrations", SearchPath: "public"}
+
+	tenants := SchemaTenants("postgres", config, []string{"acme", `we"ird`})
+	c.Assert(tenants, HasLen, 2)
+
+	c.Assert(tenants[0].Name, Equals, "acme")
+	c.Assert(tenants[0].Config.Schema, Equals, "acme")
+	c.Assert(tenants[0].Config.SearchPath, Equals, `"acme",public`)
+	c.Assert(tenants[0].Config.Datasource, Equals, "db")
+	c.Assert(tenants[1].Config.SearchPath, Equals, `"we""ird",public`)
+
+	c.Assert(config.Schema, Equals, "")
+}
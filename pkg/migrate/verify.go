@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"context"
+)
+
+// ChecksumMismatch is a migration that was modified after it was applied.
+type ChecksumMismatch struct {
+	Migration *Migration
+	Record    *Record
+}
+
+// Verify compares the checksums of the applied migrations with the ones found
+// in src and returns the migrations that were modified after they were
+// applied. Records without a checksum are not reported.
+func (m *Migrator) Verify(ctx context.Context, src Source) ([]*ChecksumMismatch, error) {
+	migrations, err := src.Find()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := m.DB.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records = ResolveSquashedRecords(migrations, records)
+
+	return checksumMismatches(migrations, records, false), nil
+}
+
+// Repair re-stamps the checksums of the applied migrations with the ones
+// found in src, accepting any modifications made to them after they were
+// applied. Records without a checksum are stamped as well.
+//
+// Returns the number of repaired records.
+func (m *Migrator) Repair(ctx context.Context, src Source) (int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	migrations, err := src.Find()
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := m.DB.Records(ctx)
+	if err != nil {
+		return 0, err
+	}
+	records = ResolveSquashedRecords(migrations, records)
+
+	repaired := 0
+	for _, mismatch := range checksumMismatches(migrations, records, true) {
+		record := *mismatch.Record
+		record.Checksum = mismatch.Migration.Checksum()
+
+		if err := m.DB.UpdateRecord(ctx, &record); err != nil {
+			return repaired, err
+		}
+
+		repaired++
+	}
+
+	return repaired, nil
+}
+
+// checksumMismatches pairs records with the migrations they were created for
+// and returns the pairs whose checksums differ. Records of unknown migrations
+// are ignored.
+func checksumMismatches(migrations []*Migration, records []*Record, includeEmpty bool) []*ChecksumMismatch {
+	byID := make(map[string]*Migration)
+	for _, migration := range migrations {
+		byID[migration.ID] = migration
+	}
+
+	var mismatches []*ChecksumMismatch
+	for _, record := range records {
+		migration, ok := byID[record.ID]
+		if !ok {
+			continue
+		}
+
+		if migration.Repeatable {
+			continue
+		}
+
+		if checksumMismatch(migration, record) || (includeEmpty && record.Checksum == "") {
+			mismatches = append(mismatches, &ChecksumMismatch{
+				Migration: migration,
+				Record:    record,
+			})
+		}
+	}
+
+	return mismatches
+}
+
+// checksumMismatch reports whether migration was modified after record was
+// created for it. Repeatable migrations are expected to be modified.
+func checksumMismatch(migration *Migration, record *Record) bool {
+	if migration == nil || migration.Repeatable || record.Checksum == "" {
+		return false
+	}
+
+	return migration.Checksum() != record.Checksum
+}
@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type VerifySuite struct {
+	db       *memDB
+	migrator *Migrator
+}
+
+var _ = Suite(&VerifySuite{})
+
+func (s *VerifySuite) SetUpTest(c *C) {
+	s.db = newMemDB()
+	s.migrator = &Migrator{DB: s.db}
+
+	_, err := s.migrator.Exec(&MemorySource{Migrations: sqlMigrations}, Up)
+	c.Assert(err, IsNil)
+}
+
+// modifiedSource returns sqlMigrations with the Up statements of the second
+// migration changed.
+func modifiedSource() *MemorySource {
+	modified := *sqlMigrations[1]
+	modified.Up = []string{"ALTER TABLE people ADD COLUMN last_name text"}
+
+	return &MemorySource{Migrations: []*Migration{sqlMigrations[0], &modified, sqlMigrations[2]}}
+}
+
+func (s *VerifySuite) TestVerifySquashed(c *C) {
+	// The records of the squashed migrations are those of the migration they
+	// were squashed into, even if the squashed migrations are still found.
+	src := modifiedSource()
+	src.Migrations = append(src.Migrations, &Migration{
+		ID:       "2_squashed",
+		Up:       []string{sqlMigrations[0].Up[0], sqlMigrations[1].Up[0]},
+		Squashes: []string{"1_create_table", "2_alter_table"},
+	})
+
+	mismatches, err := s.migrator.Verify(context.Background(), src)
+	c.Assert(err, IsNil)
+	c.Assert(mismatches, HasLen, 0)
+
+	n, err := s.migrator.Repair(context.Background(), src)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 0)
+}
+
+func (s *VerifySuite) TestChecksum(c *C) {
+	m := Migration{ID: "1", Up: []string{"a", "b"}}
+	c.Assert(m.Checksum(), HasLen, 64)
+	c.Assert(m.Checksum(), Equals, Migration{ID: "2", Up: []string{"a", "b"}, Down: []string{"c"}}.Checksum())
+	c.Assert(m.Checksum(), Not(Equals), Migration{ID: "1", Up: []string{"ab"}}.Checksum())
+}
+
+func (s *VerifySuite) TestRecordsChecksum(c *C) {
+	records, err := s.db.Records(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	for i, record := range records {
+		c.Assert(record.Checksum, Equals, sqlMigrations[i].Checksum())
+	}
+}
+
+func (s *VerifySuite) TestVerify(c *C) {
+	mismatches, err := s.migrator.Verify(context.Background(), &MemorySource{Migrations: sqlMigrations})
+	c.Assert(err, IsNil)
+	c.Assert(mismatches, HasLen, 0)
+
+	mismatches, err = s.migrator.Verify(context.Background(), modifiedSource())
+	c.Assert(err, IsNil)
+	c.Assert(mismatches, HasLen, 1)
+	c.Assert(mismatches[0].Migration.ID, Equals, "2_alter_table")
+	c.Assert(mismatches[0].Record.Checksum, Equals, sqlMigrations[1].Checksum())
+}
+
+func (s *VerifySuite) TestPlanRefusesModified(c *C) {
+	_, err := s.migrator.Plan(modifiedSource(), Down, 1)
+	c.Assert(err, FitsTypeOf, &PlanError{})
+	c.Assert(err.(*PlanError).Migration.ID, Equals, "2_alter_table")
+	c.Assert(errors.Is(err, ErrChecksumMismatch), Equals, true)
+}
+
+func (s *VerifySuite) TestRepair(c *C) {
+	src := modifiedSource()
+
+	n, err := s.migrator.Repair(context.Background(), src)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1)
+
+	mismatches, err := s.migrator.Verify(context.Background(), src)
+	c.Assert(err, IsNil)
+	c.Assert(mismatches, HasLen, 0)
+
+	_, err = s.migrator.Plan(src, Down, 1)
+	c.Assert(err, IsNil)
+}
+
+func (s *VerifySuite) TestLegacyRecords(c *C) {
+	for _, record := range s.db.records {
+		record.Checksum = ""
+	}
+
+	_, err := s.migrator.Plan(modifiedSource(), Down, 1)
+	c.Assert(err, IsNil)
+
+	n, err := s.migrator.Repair(context.Background(), &MemorySource{Migrations: sqlMigrations})
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+
+	_, err = s.migrator.Plan(modifiedSource(), Down, 1)
+	c.Assert(err, NotNil)
+}
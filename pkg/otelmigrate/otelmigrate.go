@@ -0,0 +1,164 @@
+// Package otelmigrate traces the migrations executed by a migrate.Migrator
+// with OpenTelemetry: a span per run applying or rolling back migrations, a
+// child span per migration, and a child span of the latter per statement.
+package otelmigrate
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+const instrumentationName = "github.com/shasderias/sql-migrate/pkg/otelmigrate"
+
+// Attributes of the spans.
+const (
+	MigrationIDKey        = attribute.Key("migration.id")
+	MigrationDirectionKey = attribute.Key("migration.direction")
+	MigrationCountKey     = attribute.Key("migration.count")
+	StatementKey          = attribute.Key("db.statement")
+	CommandTagKey         = attribute.Key("db.command_tag")
+	RowsAffectedKey       = attribute.Key("db.rows_affected")
+)
+
+// Instrument traces the migrations executed by m with the tracer provider tp,
+// or the global tracer provider if nil. It sets the Tracer of m and wraps its
+// DB, forwarding the optional interfaces of the DB, so it may be called before
+// or after EnableHistory.
+func Instrument(m *migrate.Migrator, tp trace.TracerProvider) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	t := &tracer{tracer: tp.Tracer(instrumentationName)}
+
+	m.DB = &DB{DB: m.DB, tracer: t}
+	m.Tracer = t
+}
+
+// tracer starts the spans of the runs and of the migrations, as children of
+// the span of their context.
+type tracer struct {
+	tracer trace.Tracer
+}
+
+var _ migrate.Tracer = (*tracer)(nil)
+
+// StartRun starts the span of a run executing migrations.
+func (t *tracer) StartRun(ctx context.Context, migrations []*migrate.PlannedMigration) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, "migrate "+migrations[0].Direction.String(),
+		trace.WithAttributes(
+			MigrationDirectionKey.String(migrations[0].Direction.String()),
+			MigrationCountKey.Int(len(migrations)),
+		))
+
+	return ctx, func(err error) { endSpan(span, err) }
+}
+
+// StartMigration starts the span of a migration.
+func (t *tracer) StartMigration(ctx context.Context, migration *migrate.PlannedMigration) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, "migration "+migration.ID,
+		trace.WithAttributes(
+			MigrationIDKey.String(migration.ID),
+			MigrationDirectionKey.String(migration.Direction.String()),
+		))
+
+	return ctx, func(err error) { endSpan(span, err) }
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// DB traces the statements executed outside of a transaction.
+type DB struct {
+	migrate.DB
+	tracer *tracer
+}
+
+func (db *DB) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	return exec(ctx, db.tracer, db.DB, sql, arguments)
+}
+
+// CreateHistoryTable, InsertHistory and History forward to the DB, if it is
+// a migrate.HistoryStore.
+func (db *DB) CreateHistoryTable(ctx context.Context) error {
+	history, ok := db.DB.(migrate.HistoryStore)
+	if !ok {
+		return fmt.Errorf("history not supported")
+	}
+	return history.CreateHistoryTable(ctx)
+}
+
+func (db *DB) InsertHistory(ctx context.Context, entry *migrate.HistoryEntry) error {
+	history, ok := db.DB.(migrate.HistoryStore)
+	if !ok {
+		return fmt.Errorf("history not supported")
+	}
+	return history.InsertHistory(ctx, entry)
+}
+
+func (db *DB) History(ctx context.Context, filter *migrate.HistoryFilter) ([]*migrate.HistoryEntry, error) {
+	history, ok := db.DB.(migrate.HistoryStore)
+	if !ok {
+		return nil, fmt.Errorf("history not supported")
+	}
+	return history.History(ctx, filter)
+}
+
+// QueryStrings forwards to the DB, if it is a migrate.StringQuerier.
+func (db *DB) QueryStrings(ctx context.Context, query string) ([]string, error) {
+	querier, ok := db.DB.(migrate.StringQuerier)
+	if !ok {
+		return nil, fmt.Errorf("tenant discovery not supported")
+	}
+	return querier.QueryStrings(ctx, query)
+}
+
+func (db *DB) Begin(ctx context.Context) (migrate.Tx, error) {
+	tx, err := db.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx, tracer: db.tracer}, nil
+}
+
+// Tx traces the statements executed in a transaction.
+type Tx struct {
+	migrate.Tx
+	tracer *tracer
+}
+
+func (tx *Tx) Exec(ctx context.Context, sql string, arguments ...interface{}) (migrate.ExecResult, error) {
+	return exec(ctx, tx.tracer, tx.Tx, sql, arguments)
+}
+
+// exec executes a statement in a span, a child of the span of the migration
+// or of the run executing it.
+func exec(ctx context.Context, t *tracer, ex migrate.SqlExecutor, sql string, arguments []interface{}) (migrate.ExecResult, error) {
+	_, span := t.tracer.Start(ctx, "statement", trace.WithAttributes(StatementKey.String(sql)))
+
+	res, err := ex.Exec(ctx, sql, arguments...)
+	if err == nil {
+		span.SetAttributes(RowsAffectedKey.Int64(res.RowsAffected()))
+
+		// pgconn.CommandTag describes the statement, e.g. "INSERT 0 1".
+		if tag, ok := res.(fmt.Stringer); ok {
+			span.SetAttributes(CommandTagKey.String(tag.String()))
+		}
+	}
+	endSpan(span, err)
+
+	return res, err
+}
@@ -0,0 +1,143 @@
+package otelmigrate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	. "gopkg.in/check.v1"
+
+	_ "github.com/shasderias/sql-migrate/pkg/db/sqlite"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_insert",
+		Up:   []string{"INSERT INTO people (id) VALUES (1), (2)"},
+		Down: []string{"DELETE FROM people"},
+	},
+}
+
+type OtelSuite struct {
+	exporter *tracetest.InMemoryExporter
+	migrator *migrate.Migrator
+	source   *migrate.MemorySource
+}
+
+var _ = Suite(&OtelSuite{})
+
+func (s *OtelSuite) SetUpTest(c *C) {
+	var err error
+	s.migrator, err = migrate.New("sqlite3", filepath.Join(c.MkDir(), "test.db"), "migrations")
+	c.Assert(err, IsNil)
+
+	s.exporter = tracetest.NewInMemoryExporter()
+	Instrument(s.migrator, sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter)))
+
+	s.source = &migrate.MemorySource{Migrations: migrations}
+}
+
+func (s *OtelSuite) TearDownTest(c *C) {
+	s.migrator.Close()
+}
+
+// spans returns the exported spans by name.
+func (s *OtelSuite) spans() map[string][]tracetest.SpanStub {
+	spans := make(map[string][]tracetest.SpanStub)
+	for _, span := range s.exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	return spans
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func (s *OtelSuite) TestSpans(c *C) {
+	n, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 2)
+
+	spans := s.spans()
+
+	c.Assert(spans["migrate up"], HasLen, 1)
+	run := spans["migrate up"][0]
+	c.Assert(attr(run, MigrationDirectionKey).AsString(), Equals, "up")
+	c.Assert(attr(run, MigrationCountKey).AsInt64(), Equals, int64(2))
+
+	c.Assert(spans["migration 1_create_table"], HasLen, 1)
+	c.Assert(spans["migration 2_insert"], HasLen, 1)
+	migration := spans["migration 2_insert"][0]
+	c.Assert(migration.Parent.SpanID(), Equals, run.SpanContext.SpanID())
+	c.Assert(attr(migration, MigrationIDKey).AsString(), Equals, "2_insert")
+	c.Assert(attr(migration, MigrationDirectionKey).AsString(), Equals, "up")
+
+	var insert *tracetest.SpanStub
+	for i, span := range spans["statement"] {
+		if attr(span, StatementKey).AsString() == migrations[1].Up[0] {
+			insert = &spans["statement"][i]
+		}
+	}
+	c.Assert(insert, NotNil)
+	c.Assert(insert.Parent.SpanID(), Equals, migration.SpanContext.SpanID())
+	c.Assert(attr(*insert, RowsAffectedKey).AsInt64(), Equals, int64(2))
+}
+
+func (s *OtelSuite) TestNoMigrations(c *C) {
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	s.exporter.Reset()
+
+	_, err = s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+	c.Assert(s.spans()["migrate up"], HasLen, 0)
+}
+
+func (s *OtelSuite) TestError(c *C) {
+	s.source.Migrations = []*migrate.Migration{{ID: "1_broken", Up: []string{"FAIL"}}}
+
+	_, err := s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, NotNil)
+
+	spans := s.spans()
+	c.Assert(spans["migrate up"], HasLen, 1)
+	c.Assert(spans["migrate up"][0].Status.Code, Equals, codes.Error)
+	c.Assert(spans["migration 1_broken"], HasLen, 1)
+	c.Assert(spans["migration 1_broken"][0].Status.Code, Equals, codes.Error)
+	c.Assert(spans["statement"], Not(HasLen), 0)
+}
+
+func (s *OtelSuite) TestHistoryError(c *C) {
+	ctx := context.Background()
+	// Instrument forwards the history of the DB.
+	c.Assert(s.migrator.EnableHistory(ctx), IsNil)
+	_, err := s.migrator.DB.Exec(ctx, "DROP TABLE migrations_history")
+	c.Assert(err, IsNil)
+
+	_, err = s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, NotNil)
+
+	spans := s.spans()
+	c.Assert(spans["migrate up"], HasLen, 1)
+	c.Assert(spans["migrate up"][0].Status.Code, Equals, codes.Error)
+	c.Assert(spans["migration 1_create_table"], HasLen, 1)
+	c.Assert(spans["migration 1_create_table"][0].Status.Code, Equals, codes.Unset)
+}
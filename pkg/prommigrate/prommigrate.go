@@ -0,0 +1,170 @@
+// Package prommigrate reports the state of the migrations of a database as
+// Prometheus metrics: the number of pending and dirty migrations, the last
+// applied migration and how long the applied migrations took.
+package prommigrate
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+// DurationBuckets are the buckets of the histogram of the durations of the
+// applied migrations, in seconds.
+var DurationBuckets = []float64{.01, .1, 1, 10, 60, 300, 1800}
+
+var (
+	pendingDesc = prometheus.NewDesc(
+		"sql_migrate_pending_migrations",
+		"Number of migrations not applied yet.",
+		nil, nil)
+	dirtyDesc = prometheus.NewDesc(
+		"sql_migrate_dirty_migrations",
+		"Number of migrations that failed halfway without a transaction.",
+		nil, nil)
+	appliedDesc = prometheus.NewDesc(
+		"sql_migrate_applied_migrations",
+		"Number of applied migrations.",
+		nil, nil)
+	lastAppliedDesc = prometheus.NewDesc(
+		"sql_migrate_last_applied_timestamp_seconds",
+		"Time the last applied migration was applied at.",
+		nil, nil)
+	lastAppliedInfoDesc = prometheus.NewDesc(
+		"sql_migrate_last_applied_migration_info",
+		"The last applied migration, always 1.",
+		[]string{"migration"}, nil)
+	durationDesc = prometheus.NewDesc(
+		"sql_migrate_migration_duration_seconds",
+		"Time the applied migrations took to execute.",
+		nil, nil)
+)
+
+// Collector is a prometheus.Collector reading the state of the migrations
+// from the database of a Migrator every time it is collected.
+type Collector struct {
+	migrator *migrate.Migrator
+	source   migrate.Source
+
+	// Timeout is the maximum time reading the state of the migrations can
+	// take. 0 means no limit.
+	Timeout time.Duration
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a Collector of the migrations of src applied by m.
+func NewCollector(m *migrate.Migrator, src migrate.Source) *Collector {
+	return &Collector{
+		migrator: m,
+		source:   src,
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pendingDesc
+	ch <- dirtyDesc
+	ch <- appliedDesc
+	ch <- lastAppliedDesc
+	ch <- lastAppliedInfoDesc
+	ch <- durationDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	migrations, records, err := c.load(ctx)
+	if err != nil {
+		for _, desc := range []*prometheus.Desc{pendingDesc, dirtyDesc, appliedDesc} {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+		}
+		return
+	}
+
+	// Unlike planning, counting the pending migrations doesn't fail on
+	// dirty migrations, which are reported separately.
+	byID := make(map[string]*migrate.Record, len(records))
+	var applied []*migrate.Record
+	dirty := 0
+	for _, r := range records {
+		byID[r.ID] = r
+		if r.Dirty {
+			dirty++
+		} else {
+			applied = append(applied, r)
+		}
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		r, ok := byID[m.ID]
+		if !ok || r.Dirty || m.Repeatable && r.Checksum != m.Checksum() {
+			pending++
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(pendingDesc, prometheus.GaugeValue, float64(pending))
+	ch <- prometheus.MustNewConstMetric(dirtyDesc, prometheus.GaugeValue, float64(dirty))
+	ch <- prometheus.MustNewConstMetric(appliedDesc, prometheus.GaugeValue, float64(len(applied)))
+
+	var (
+		last    *migrate.Record
+		count   uint64
+		sum     float64
+		buckets = make(map[float64]uint64, len(DurationBuckets))
+	)
+	for _, b := range DurationBuckets {
+		buckets[b] = 0
+	}
+	for _, r := range applied {
+		if last == nil || r.AppliedAt.After(last.AppliedAt) ||
+			r.AppliedAt.Equal(last.AppliedAt) && r.ID > last.ID {
+			last = r
+		}
+
+		// Baselined migrations weren't executed by sql-migrate.
+		if r.Baseline {
+			continue
+		}
+
+		seconds := r.Duration.Seconds()
+		count++
+		sum += seconds
+		for _, b := range DurationBuckets {
+			if seconds <= b {
+				buckets[b]++
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstHistogram(durationDesc, count, sum, buckets)
+
+	if last != nil {
+		ch <- prometheus.MustNewConstMetric(lastAppliedDesc, prometheus.GaugeValue,
+			float64(last.AppliedAt.UnixNano())/1e9)
+		ch <- prometheus.MustNewConstMetric(lastAppliedInfoDesc, prometheus.GaugeValue, 1, last.ID)
+	}
+}
+
+// load returns the migrations found in the source and the records of the
+// migrations applied to the database, dirty ones included.
+func (c *Collector) load(ctx context.Context) ([]*migrate.Migration, []*migrate.Record, error) {
+	migrations, err := c.source.Find()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := c.migrator.Records(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return migrations, migrate.ResolveSquashedRecords(migrations, records), nil
+}
@@ -0,0 +1,145 @@
+package prommigrate
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	. "gopkg.in/check.v1"
+
+	_ "github.com/shasderias/sql-migrate/pkg/db/sqlite"
+	"github.com/shasderias/sql-migrate/pkg/migrate"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+var migrations = []*migrate.Migration{
+	{
+		ID:   "1_create_table",
+		Up:   []string{"CREATE TABLE people (id int)"},
+		Down: []string{"DROP TABLE people"},
+	},
+	{
+		ID:   "2_alter_table",
+		Up:   []string{"ALTER TABLE people ADD COLUMN first_name text"},
+		Down: []string{"SELECT 0"},
+	},
+}
+
+type PromSuite struct {
+	migrator  *migrate.Migrator
+	source    *migrate.MemorySource
+	collector *Collector
+}
+
+var _ = Suite(&PromSuite{})
+
+func (s *PromSuite) SetUpTest(c *C) {
+	var err error
+	s.migrator, err = migrate.New("sqlite3", filepath.Join(c.MkDir(), "test.db"), "migrations")
+	c.Assert(err, IsNil)
+
+	s.source = &migrate.MemorySource{Migrations: migrations}
+	s.collector = NewCollector(s.migrator, s.source)
+}
+
+func (s *PromSuite) TearDownTest(c *C) {
+	s.migrator.Close()
+}
+
+// gather returns the metric families collected, by name.
+func (s *PromSuite) gather(c *C) map[string]*dto.MetricFamily {
+	reg := prometheus.NewPedanticRegistry()
+	c.Assert(reg.Register(s.collector), IsNil)
+
+	families, err := reg.Gather()
+	c.Assert(err, IsNil)
+
+	byName := make(map[string]*dto.MetricFamily)
+	for _, f := range families {
+		byName[f.GetName()] = f
+	}
+	return byName
+}
+
+func (s *PromSuite) TestNoMigrationsApplied(c *C) {
+	err := testutil.CollectAndCompare(s.collector, strings.NewReader(`
+# HELP sql_migrate_applied_migrations Number of applied migrations.
+# TYPE sql_migrate_applied_migrations gauge
+sql_migrate_applied_migrations 0
+# HELP sql_migrate_pending_migrations Number of migrations not applied yet.
+# TYPE sql_migrate_pending_migrations gauge
+sql_migrate_pending_migrations 2
+`), "sql_migrate_applied_migrations", "sql_migrate_pending_migrations")
+	c.Assert(err, IsNil)
+
+	families := s.gather(c)
+	c.Assert(families["sql_migrate_last_applied_timestamp_seconds"], IsNil)
+	c.Assert(families["sql_migrate_last_applied_migration_info"], IsNil)
+	c.Assert(families["sql_migrate_migration_duration_seconds"].Metric[0].Histogram.GetSampleCount(), Equals, uint64(0))
+}
+
+func (s *PromSuite) TestMigrationsApplied(c *C) {
+	_, err := s.migrator.ExecMax(s.source, migrate.Up, 1)
+	c.Assert(err, IsNil)
+
+	families := s.gather(c)
+	c.Assert(families["sql_migrate_pending_migrations"].Metric[0].Gauge.GetValue(), Equals, 1.0)
+	c.Assert(families["sql_migrate_applied_migrations"].Metric[0].Gauge.GetValue(), Equals, 1.0)
+
+	last := families["sql_migrate_last_applied_timestamp_seconds"].Metric
+	c.Assert(last, HasLen, 1)
+	c.Assert(last[0].Label, HasLen, 0)
+	c.Assert(last[0].Gauge.GetValue() > 0, Equals, true)
+
+	info := families["sql_migrate_last_applied_migration_info"].Metric
+	c.Assert(info, HasLen, 1)
+	c.Assert(info[0].Label[0].GetValue(), Equals, "1_create_table")
+	c.Assert(info[0].Gauge.GetValue(), Equals, 1.0)
+
+	_, err = s.migrator.Exec(s.source, migrate.Up)
+	c.Assert(err, IsNil)
+
+	// The durations of all the applied migrations are in a single series.
+	durations := s.gather(c)["sql_migrate_migration_duration_seconds"].Metric
+	c.Assert(durations, HasLen, 1)
+	c.Assert(durations[0].Label, HasLen, 0)
+	c.Assert(durations[0].Histogram.GetSampleCount(), Equals, uint64(2))
+	c.Assert(durations[0].Histogram.Bucket, HasLen, len(DurationBuckets))
+}
+
+func (s *PromSuite) TestBaselineExcludedFromDurations(c *C) {
+	_, err := s.migrator.Baseline(s.source, "1_create_table", false)
+	c.Assert(err, IsNil)
+
+	families := s.gather(c)
+	c.Assert(families["sql_migrate_applied_migrations"].Metric[0].Gauge.GetValue(), Equals, 1.0)
+	c.Assert(families["sql_migrate_migration_duration_seconds"].Metric[0].Histogram.GetSampleCount(), Equals, uint64(0))
+}
+
+func (s *PromSuite) TestDirtyMigration(c *C) {
+	_, err := s.migrator.ExecMax(s.source, migrate.Up, 1)
+	c.Assert(err, IsNil)
+
+	records, err := s.migrator.Records(context.Background())
+	c.Assert(err, IsNil)
+	records[0].Dirty = true
+	c.Assert(s.migrator.UpdateRecord(context.Background(), records[0]), IsNil)
+
+	err = testutil.CollectAndCompare(s.collector, strings.NewReader(`
+# HELP sql_migrate_applied_migrations Number of applied migrations.
+# TYPE sql_migrate_applied_migrations gauge
+sql_migrate_applied_migrations 0
+# HELP sql_migrate_dirty_migrations Number of migrations that failed halfway without a transaction.
+# TYPE sql_migrate_dirty_migrations gauge
+sql_migrate_dirty_migrations 1
+# HELP sql_migrate_pending_migrations Number of migrations not applied yet.
+# TYPE sql_migrate_pending_migrations gauge
+sql_migrate_pending_migrations 2
+`), "sql_migrate_applied_migrations", "sql_migrate_dirty_migrations", "sql_migrate_pending_migrations")
+	c.Assert(err, IsNil)
+}
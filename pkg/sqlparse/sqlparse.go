@@ -19,8 +19,19 @@ type ParsedMigration struct {
 	UpStatements   []string
 	DownStatements []string
 
+	// UpLines and DownLines are the lines, starting at 1, the Up and Down
+	// statements start at in the parsed file.
+	UpLines   []int
+	DownLines []int
+
 	DisableTransactionUp   bool
 	DisableTransactionDown bool
+
+	// Repeatable is set by the Repeatable command.
+	Repeatable bool
+	// Squashes lists the options of the Squashes commands, the IDs of the
+	// migrations squashed into this one.
+	Squashes []string
 }
 
 var (
@@ -121,6 +132,7 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 	}
 
 	var buf bytes.Buffer
+	var lineNo, stmtLine int
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
@@ -130,6 +142,8 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNo++
+
 		// ignore comment except beginning with '-- +'
 		if strings.HasPrefix(line, "-- ") && !strings.HasPrefix(line, "-- +") {
 			continue
@@ -163,6 +177,14 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 				}
 				break
 
+			case "Repeatable":
+				p.Repeatable = true
+				break
+
+			case "Squashes":
+				p.Squashes = append(p.Squashes, cmd.Options...)
+				break
+
 			case "StatementBegin":
 				if currentDirection != directionNone {
 					ignoreSemicolons = true
@@ -185,6 +207,9 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 		isLineSeparator := !ignoreSemicolons && len(LineSeparator) > 0 && line == LineSeparator
 
 		if !isLineSeparator && !strings.HasPrefix(line, "-- +") {
+			if buf.Len() == 0 {
+				stmtLine = lineNo
+			}
 			if _, err := buf.WriteString(line + "\n"); err != nil {
 				return nil, err
 			}
@@ -198,9 +223,11 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 			switch currentDirection {
 			case directionUp:
 				p.UpStatements = append(p.UpStatements, buf.String())
+				p.UpLines = append(p.UpLines, stmtLine)
 
 			case directionDown:
 				p.DownStatements = append(p.DownStatements, buf.String())
+				p.DownLines = append(p.DownLines, stmtLine)
 
 			default:
 				panic("impossible state")
@@ -233,3 +260,53 @@ func ParseMigration(r io.ReadSeeker) (*ParsedMigration, error) {
 
 	return p, nil
 }
+
+// Format returns the source of p, which ParseMigration parses back into p,
+// apart from the lines of the statements.
+// Statements containing semicolons before their end are wrapped in
+// 'StatementBegin' and 'StatementEnd' annotations. No blank lines are added,
+// as they would become part of the statements.
+func Format(p *ParsedMigration) []byte {
+	var buf bytes.Buffer
+
+	if p.Repeatable {
+		buf.WriteString(sqlCmdPrefix + "Repeatable\n")
+	}
+	for _, id := range p.Squashes {
+		buf.WriteString(sqlCmdPrefix + "Squashes " + id + "\n")
+	}
+
+	formatSection(&buf, "Up", p.DisableTransactionUp, p.UpStatements)
+	formatSection(&buf, "Down", p.DisableTransactionDown, p.DownStatements)
+
+	return buf.Bytes()
+}
+
+func formatSection(buf *bytes.Buffer, direction string, noTransaction bool, statements []string) {
+	buf.WriteString(sqlCmdPrefix + direction)
+	if noTransaction {
+		buf.WriteString(" " + optionNoTransaction)
+	}
+	buf.WriteString("\n")
+
+	for _, stmt := range statements {
+		if !strings.HasSuffix(stmt, "\n") {
+			stmt += "\n"
+		}
+
+		if isPlainStatement(stmt) {
+			buf.WriteString(stmt)
+		} else {
+			buf.WriteString(sqlCmdPrefix + "StatementBegin\n")
+			buf.WriteString(stmt)
+			buf.WriteString(sqlCmdPrefix + "StatementEnd\n")
+		}
+	}
+}
+
+// isPlainStatement reports whether stmt is parsed as a single statement
+// without 'StatementBegin' and 'StatementEnd' annotations.
+func isPlainStatement(stmt string) bool {
+	p, err := ParseMigration(strings.NewReader(sqlCmdPrefix + "Up\n" + stmt))
+	return err == nil && len(p.UpStatements) == 1 && p.UpStatements[0] == stmt
+}
@@ -1,6 +1,7 @@
 package sqlparse
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
@@ -126,6 +127,44 @@ func (s *SqlParseSuite) TestCustomTerminator(c *C) {
 	}
 }
 
+func (s *SqlParseSuite) TestRepeatable(c *C) {
+	migration, err := ParseMigration(strings.NewReader(repeatabletxt))
+	c.Assert(err, IsNil)
+	c.Assert(migration.Repeatable, Equals, true)
+	c.Assert(migration.UpStatements, HasLen, 1)
+	c.Assert(migration.DownStatements, HasLen, 0)
+
+	migration, err = ParseMigration(strings.NewReader(functxt))
+	c.Assert(err, IsNil)
+	c.Assert(migration.Repeatable, Equals, false)
+}
+
+func (s *SqlParseSuite) TestLines(c *C) {
+	migration, err := ParseMigration(strings.NewReader(functxt))
+	c.Assert(err, IsNil)
+	c.Assert(migration.UpLines, DeepEquals, []int{2, 7})
+	c.Assert(migration.DownLines, DeepEquals, []int{30, 33})
+}
+
+func (s *SqlParseSuite) TestFormat(c *C) {
+	for _, txt := range []string{functxt, multitxt, repeatabletxt} {
+		migration, err := ParseMigration(strings.NewReader(txt))
+		c.Assert(err, IsNil)
+		migration.Squashes = []string{"1_a.sql", "2_b.sql"}
+		migration.DisableTransactionDown = true
+
+		formatted, err := ParseMigration(bytes.NewReader(Format(migration)))
+		c.Assert(err, IsNil)
+		formatted.UpLines, formatted.DownLines = migration.UpLines, migration.DownLines
+		c.Assert(formatted, DeepEquals, migration)
+	}
+}
+
+var repeatabletxt = `-- +migrate Repeatable
+-- +migrate Up
+CREATE OR REPLACE VIEW adults AS SELECT * FROM people WHERE age >= 18;
+`
+
 var functxt = `-- +migrate Up
 CREATE TABLE IF NOT EXISTS histories (
   id                BIGSERIAL  PRIMARY KEY,